@@ -0,0 +1,140 @@
+package influxquerybuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrEmptyBatch A batch without statements
+var ErrEmptyBatch = errors.New("influxquerybuilder: batch has no statements")
+
+// Batch Batch of statements sent in one request
+type Batch struct {
+	statements []Statement
+}
+
+// BatchResult Results of a batch, by originating statement
+type BatchResult struct {
+	statements []Statement
+	results    []Result
+}
+
+// NewBatch New Batch
+func NewBatch(statements ...Statement) *Batch {
+	return (&Batch{}).Add(statements...)
+}
+
+// Add Add statements, a nested batch is flattened
+func (b *Batch) Add(statements ...Statement) *Batch {
+	for _, s := range statements {
+		if nested, ok := s.(*Batch); ok {
+			b.statements = append(b.statements, nested.statements...)
+			continue
+		}
+
+		b.statements = append(b.statements, s)
+	}
+
+	return b
+}
+
+// Len Number of statements
+func (b *Batch) Len() int {
+	return len(b.statements)
+}
+
+// Validate Validate every statement
+func (b *Batch) Validate() error {
+	if len(b.statements) == 0 {
+		return ErrEmptyBatch
+	}
+
+	for i, s := range b.statements {
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("statement %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// Build Build statements joined with ";", empty if any statement is invalid
+func (b *Batch) Build() string {
+	if b.Validate() != nil {
+		return ""
+	}
+
+	queries := make([]string, len(b.statements))
+
+	for i, s := range b.statements {
+		queries[i] = s.Build()
+	}
+
+	return strings.Join(queries, ";")
+}
+
+// Execute Execute the batch and split the results by statement_id
+func (b *Batch) Execute(ctx context.Context, executor Executor) (*BatchResult, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp, err := executor.Execute(ctx, b.Build())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resp.Error(); err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, len(b.statements))
+
+	for i := range results {
+		results[i].StatementID = i
+	}
+
+	for _, r := range resp.Results {
+		if r.StatementID < 0 || r.StatementID >= len(results) {
+			return nil, fmt.Errorf("influxquerybuilder: unexpected statement_id %d", r.StatementID)
+		}
+
+		results[r.StatementID] = r
+	}
+
+	return &BatchResult{statements: b.statements, results: results}, nil
+}
+
+// Get Result of the given statement, matched by pointer identity. Use Index
+// for statements that are not pointers
+func (r *BatchResult) Get(statement Statement) (Result, bool) {
+	v := reflect.ValueOf(statement)
+	if v.Kind() != reflect.Ptr {
+		return Result{}, false
+	}
+
+	for i, s := range r.statements {
+		if sv := reflect.ValueOf(s); sv.Type() == v.Type() && sv.Pointer() == v.Pointer() {
+			return r.results[i], true
+		}
+	}
+
+	return Result{}, false
+}
+
+// Index Result of the i-th statement
+func (r *BatchResult) Index(i int) (Result, bool) {
+	if i < 0 || i >= len(r.results) {
+		return Result{}, false
+	}
+
+	return r.results[i], true
+}
+
+// Results Results in statement order
+func (r *BatchResult) Results() []Result {
+	return r.results
+}
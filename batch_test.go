@@ -0,0 +1,121 @@
+package influxquerybuilder
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type fakeExecutor struct {
+	query    string
+	response *Response
+	err      error
+}
+
+func (f *fakeExecutor) Execute(ctx context.Context, query string) (*Response, error) {
+	f.query = query
+	return f.response, f.err
+}
+
+func TestBatchBuild(t *testing.T) {
+	expected := `SELECT "temperature" FROM "measurement" LIMIT 1;SHOW USERS;DROP DATABASE "old"`
+	q := NewBatch(
+		New().Select("temperature").From("measurement").Limit(1),
+		ShowUsers(),
+	).
+		Add(NewBatch(DropDatabase("old"))).
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestBatchValidate(t *testing.T) {
+	assert(t, NewBatch().Validate(), ErrEmptyBatch)
+
+	batch := NewBatch(
+		New().Select("temperature").From("measurement"),
+		New().Select("humidity"),
+	)
+	assert(t, errors.Is(batch.Validate(), ErrMissingMeasurement), true)
+	assert(t, batch.Build(), "")
+}
+
+func TestBatchExecute(t *testing.T) {
+	temperature := New().Select("temperature").From("measurement")
+	humidity := New().Select("humidity").From("measurement")
+	executor := &fakeExecutor{
+		response: &Response{
+			Results: []Result{
+				{StatementID: 1, Series: []Series{{Name: "measurement", Columns: []string{"time", "humidity"}}}},
+				{StatementID: 0, Err: "boom"},
+			},
+		},
+	}
+
+	res, err := NewBatch(temperature, humidity).Execute(context.Background(), executor)
+	assert(t, err, nil)
+	assert(t, executor.query, `SELECT "temperature" FROM "measurement";SELECT "humidity" FROM "measurement"`)
+
+	r, ok := res.Get(humidity)
+	assert(t, ok, true)
+	assert(t, r.Series[0].Columns[1], "humidity")
+
+	r, ok = res.Get(temperature)
+	assert(t, ok, true)
+	assert(t, r.Error().Error(), "boom")
+
+	_, ok = res.Get(ShowUsers())
+	assert(t, ok, false)
+	assert(t, len(res.Results()), 2)
+
+	r, ok = res.Index(1)
+	assert(t, ok, true)
+	assert(t, r.StatementID, 1)
+
+	_, ok = res.Index(2)
+	assert(t, ok, false)
+}
+
+// valueStatement a statement of a non comparable type
+type valueStatement struct {
+	queries []string
+}
+
+func (s valueStatement) Validate() error {
+	return nil
+}
+
+func (s valueStatement) Build() string {
+	return strings.Join(s.queries, ";")
+}
+
+func TestBatchGetValue(t *testing.T) {
+	statement := valueStatement{queries: []string{"SHOW USERS"}}
+	executor := &fakeExecutor{response: &Response{Results: []Result{{StatementID: 0}}}}
+
+	res, err := NewBatch(statement).Execute(context.Background(), executor)
+	assert(t, err, nil)
+
+	_, ok := res.Get(statement)
+	assert(t, ok, false)
+
+	_, ok = res.Index(0)
+	assert(t, ok, true)
+}
+
+func TestBatchExecuteErrors(t *testing.T) {
+	batch := NewBatch(New().Select("temperature").From("measurement"))
+
+	_, err := batch.Execute(context.Background(), &fakeExecutor{err: errors.New("down")})
+	assert(t, err.Error(), "down")
+
+	_, err = batch.Execute(context.Background(), &fakeExecutor{response: &Response{Err: "bad request"}})
+	assert(t, err.Error(), "bad request")
+
+	_, err = batch.Execute(context.Background(), &fakeExecutor{response: &Response{Results: []Result{{StatementID: 3}}}})
+	assert(t, err != nil, true)
+
+	_, err = batch.Execute(context.Background(), &fakeExecutor{})
+	assert(t, err, ErrEmptyResponse)
+}
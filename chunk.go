@@ -0,0 +1,461 @@
+package influxquerybuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrMissingTimeRange Query without both a lower and an upper time bound
+var ErrMissingTimeRange = errors.New("influxquerybuilder: query needs a time range with lower and upper bounds")
+
+// mergeFunctions How results of aggregates over consecutive windows combine
+var mergeFunctions = map[string]string{
+	"SUM":   "SUM",
+	"COUNT": "SUM",
+	"MIN":   "MIN",
+	"MAX":   "MAX",
+	"FIRST": "FIRST",
+	"LAST":  "LAST",
+}
+
+// Chunks Sub-queries of a query over consecutive time windows
+type Chunks struct {
+	Queries []QueryBuilder
+
+	desc      bool
+	grouped   bool
+	functions []string
+}
+
+// SplitTimeRange Split a query with a time range into at most n sub-queries
+// over consecutive windows. Windows are aligned to the GROUP BY time interval,
+// so no interval spans two sub-queries
+func SplitTimeRange(builder QueryBuilder, n int) (*Chunks, error) {
+	q, ok := builder.(*Query)
+	if !ok {
+		return nil, unsupported("split of %T", builder)
+	}
+
+	if n < 1 {
+		n = 1
+	}
+
+	if q._limit || q._offset {
+		return nil, unsupported("split with LIMIT or OFFSET")
+	}
+
+	base, times, err := q.withoutTimeCriteria()
+	if err != nil {
+		return nil, err
+	}
+
+	start, startOp, end, endOp, err := timeRange(times)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := &Chunks{desc: q.order == "DESC", grouped: q.groupByTime != ""}
+
+	// Aligned windows keep every time group in a single sub-query
+	if !chunks.grouped {
+		if chunks.functions, err = q.mergeFunctions(); err != nil {
+			return nil, err
+		}
+	}
+
+	var interval time.Duration
+	if q.groupByTime != "" {
+		literal := strings.TrimSuffix(strings.TrimPrefix(q.groupByTime, "time("), ")")
+		d, err := ParseDuration(literal)
+		if err != nil {
+			return nil, unsupported("split of GROUP BY %s", q.groupByTime)
+		}
+
+		interval, _ = toTimeDuration(d)
+	}
+
+	origin := start
+	if interval > 0 {
+		origin = alignTime(start, interval)
+	}
+
+	step := (end.Sub(origin) + time.Duration(n) - 1) / time.Duration(n)
+	if interval > 0 {
+		step = (step + interval - 1) / interval * interval
+	}
+
+	if step <= 0 {
+		step = end.Sub(start) + 1
+	}
+
+	for from := start; from.Before(end) || from.Equal(end); {
+		to := origin.Add(step)
+		origin = to
+
+		sub := *base
+		sub.and = append([]Tag(nil), base.and...)
+
+		op := Gte
+		if from.Equal(start) {
+			op = Operator(startOp)
+		}
+		sub.addCriteria("time", op, formatTime(from))
+
+		if to.Before(end) {
+			sub.addCriteria("time", Lt, formatTime(to))
+		} else {
+			sub.addCriteria("time", Operator(endOp), formatTime(end))
+		}
+
+		chunks.Queries = append(chunks.Queries, &sub)
+
+		if !to.Before(end) {
+			break
+		}
+
+		from = to
+	}
+
+	if chunks.desc {
+		for i, j := 0, len(chunks.Queries)-1; i < j; i, j = i+1, j-1 {
+			chunks.Queries[i], chunks.Queries[j] = chunks.Queries[j], chunks.Queries[i]
+		}
+	}
+
+	return chunks, nil
+}
+
+// mergeFunctions merge function of each selected column, empty for raw columns
+func (q *Query) mergeFunctions() ([]string, error) {
+	items, err := q.selectItems()
+	if err != nil {
+		return nil, err
+	}
+
+	functions := make([]string, len(items))
+	aggregates := 0
+
+	for i, item := range items {
+		if item.function == "" {
+			continue
+		}
+
+		fn, ok := mergeFunctions[item.function]
+		if !ok {
+			return nil, unsupported("split of %s", item.function)
+		}
+
+		functions[i] = fn
+		aggregates++
+	}
+
+	if aggregates > 0 && aggregates < len(items) {
+		return nil, unsupported("split of aggregates mixed with raw fields")
+	}
+
+	return functions, nil
+}
+
+// timeRange lower and upper bound of time criteria with their operators
+func timeRange(times []Tag) (time.Time, string, time.Time, string, error) {
+	var start, end time.Time
+	var startOp, endOp string
+
+	for _, tag := range times {
+		t, ok := criteriaTime(tag.value)
+		if !ok {
+			return start, "", end, "", unsupported("time value %v", tag.value)
+		}
+
+		switch Operator(tag.op) {
+		case Gt, Gte:
+			if startOp == "" || t.After(start) {
+				start, startOp = t, tag.op
+			}
+		case Lt, Lte:
+			if endOp == "" || t.Before(end) {
+				end, endOp = t, tag.op
+			}
+		default:
+			return start, "", end, "", unsupported("time %s", tag.op)
+		}
+	}
+
+	if startOp == "" || endOp == "" || end.Before(start) {
+		return start, "", end, "", ErrMissingTimeRange
+	}
+
+	return start, startOp, end, endOp, nil
+}
+
+// criteriaTime time of a criteria value, RFC3339 strings, time.Time or
+// nanoseconds since the epoch
+func criteriaTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	case time.Time:
+		return v, true
+	case int:
+		return time.Unix(0, int64(v)).UTC(), true
+	case int64:
+		return time.Unix(0, v).UTC(), true
+	}
+
+	return time.Time{}, false
+}
+
+// alignTime truncates a time to a multiple of interval since the epoch, as
+// InfluxDB aligns GROUP BY time buckets
+func alignTime(t time.Time, interval time.Duration) time.Time {
+	ns := t.UnixNano()
+	rem := ns % int64(interval)
+	if rem < 0 {
+		rem += int64(interval)
+	}
+
+	return time.Unix(0, ns-rem).UTC()
+}
+
+// bracketOr moves criteria with top level OR into WHERE brackets, so that
+// criteria added afterwards apply to all of them
+func (q *Query) bracketOr() {
+	if q.or == nil && q.orBrackets == nil {
+		return
+	}
+
+	criteria := &Query{
+		where:         q.where,
+		whereBrackets: q.whereBrackets,
+		and:           q.and,
+		or:            q.or,
+		andBrackets:   q.andBrackets,
+		orBrackets:    q.orBrackets,
+	}
+
+	q.where, q.and, q.or, q.andBrackets, q.orBrackets = Tag{}, nil, nil, nil, nil
+	q.whereBrackets = criteria
+}
+
+// addCriteria WHERE criteria, or AND criteria when the query already has some
+func (q *Query) addCriteria(key string, op Operator, value interface{}) {
+	if q.where == (Tag{}) && q.whereBrackets == nil {
+		q.Where(key, string(op), value)
+	} else {
+		q.And(key, string(op), value)
+	}
+}
+
+// Execute Execute the sub-queries one after another and merge their results
+func (c *Chunks) Execute(ctx context.Context, executor Executor) (Result, error) {
+	results := make([]Result, 0, len(c.Queries))
+
+	for _, q := range c.Queries {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+
+		resp, err := executor.Execute(ctx, q.Build())
+		if err != nil {
+			return Result{}, err
+		}
+
+		if err := resp.Error(); err != nil {
+			return Result{}, err
+		}
+
+		for _, r := range resp.Results {
+			if err := r.Error(); err != nil {
+				return Result{}, err
+			}
+
+			results = append(results, r)
+		}
+	}
+
+	return c.Merge(results)
+}
+
+// Merge Merge the results of the sub-queries, in the order of Queries. Raw
+// and time grouped series are concatenated, aggregates over the whole range
+// are re-aggregated
+func (c *Chunks) Merge(results []Result) (Result, error) {
+	merged := Result{}
+	index := map[string]int{}
+
+	for _, r := range results {
+		merged.Messages = append(merged.Messages, r.Messages...)
+
+		for _, s := range r.Series {
+			key := seriesKey(s)
+
+			i, ok := index[key]
+			if !ok {
+				index[key] = len(merged.Series)
+				values := make([][]interface{}, len(s.Values))
+				for j, row := range s.Values {
+					values[j] = append([]interface{}(nil), row...)
+				}
+				s.Values = values
+				merged.Series = append(merged.Series, s)
+				continue
+			}
+
+			if err := c.mergeSeries(&merged.Series[i], s); err != nil {
+				return Result{}, err
+			}
+		}
+	}
+
+	return merged, nil
+}
+
+func (c *Chunks) mergeSeries(into *Series, s Series) error {
+	if c.grouped || len(c.functions) == 0 || c.functions[0] == "" {
+		into.Values = append(into.Values, s.Values...)
+		return nil
+	}
+
+	if len(into.Values) == 0 {
+		into.Values = append(into.Values, s.Values...)
+		return nil
+	}
+
+	for _, row := range s.Values {
+		if len(row) != len(into.Values[0]) || len(row) != len(c.functions)+1 {
+			return fmt.Errorf("influxquerybuilder: %d columns in series %s, expected %d", len(row), s.Name, len(c.functions)+1)
+		}
+
+		acc := into.Values[0]
+
+		// Column 0 is the time, which is the one of the earliest window
+		if c.desc {
+			acc[0] = row[0]
+		}
+
+		for i, fn := range c.functions {
+			acc[i+1] = mergeValue(fn, acc[i+1], row[i+1], c.desc)
+		}
+	}
+
+	return nil
+}
+
+// mergeValue combines the values of an aggregate from two windows, b is
+// from the later window unless desc is set
+func mergeValue(fn string, a, b interface{}, desc bool) interface{} {
+	if a == nil {
+		return b
+	}
+
+	if b == nil {
+		return a
+	}
+
+	switch fn {
+	case "FIRST":
+		if desc {
+			return b
+		}
+		return a
+	case "LAST":
+		if desc {
+			return a
+		}
+		return b
+	}
+
+	// Integers, json.Number included, are merged exactly
+	if x, ok := integer(a); ok {
+		if y, ok := integer(b); ok {
+			switch {
+			case fn == "SUM":
+				return x + y
+			case fn == "MIN" && y < x, fn == "MAX" && y > x:
+				return b
+			}
+
+			return a
+		}
+	}
+
+	x, ok := number(a)
+	if !ok {
+		return a
+	}
+
+	y, ok := number(b)
+	if !ok {
+		return a
+	}
+
+	switch fn {
+	case "SUM":
+		return x + y
+	case "MIN":
+		if y < x {
+			return b
+		}
+	case "MAX":
+		if y > x {
+			return b
+		}
+	}
+
+	return a
+}
+
+// integer value of a decoded integer, including integral json.Number
+func integer(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case interface{ Int64() (int64, error) }:
+		i, err := n.Int64()
+		return i, err == nil
+	}
+
+	return 0, false
+}
+
+// number float value of a decoded number
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case interface{ Float64() (float64, error) }:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+// seriesKey identifies a series by name and tags
+func seriesKey(s Series) string {
+	keys := make([]string, 0, len(s.Tags))
+	for k := range s.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(s.Name)
+
+	for _, k := range keys {
+		b.WriteString("," + k + "=" + s.Tags[k])
+	}
+
+	return b.String()
+}
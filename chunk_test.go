@@ -0,0 +1,241 @@
+package influxquerybuilder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// scriptedExecutor answers each query with the next response
+type scriptedExecutor struct {
+	queries   []string
+	responses []*Response
+}
+
+func (s *scriptedExecutor) Execute(ctx context.Context, query string) (*Response, error) {
+	s.queries = append(s.queries, query)
+
+	if len(s.responses) == 0 {
+		return &Response{Results: []Result{{}}}, nil
+	}
+
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+
+	return resp, nil
+}
+
+func buildAll(queries []QueryBuilder) []string {
+	built := make([]string, len(queries))
+	for i, q := range queries {
+		built[i] = q.Build()
+	}
+
+	return built
+}
+
+func TestSplitTimeRange(t *testing.T) {
+	q := New().
+		Select("value").
+		From("cpu").
+		Where("host", "=", "a").
+		And("time", ">=", "2020-01-01T00:00:00Z").
+		And("time", "<=", "2020-01-01T03:00:00Z")
+
+	chunks, err := SplitTimeRange(q, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`SELECT "value" FROM "cpu" WHERE "host" = 'a' AND "time" >= '2020-01-01T00:00:00Z' AND "time" < '2020-01-01T01:00:00Z'`,
+		`SELECT "value" FROM "cpu" WHERE "host" = 'a' AND "time" >= '2020-01-01T01:00:00Z' AND "time" < '2020-01-01T02:00:00Z'`,
+		`SELECT "value" FROM "cpu" WHERE "host" = 'a' AND "time" >= '2020-01-01T02:00:00Z' AND "time" <= '2020-01-01T03:00:00Z'`,
+	}
+	assert(t, reflect.DeepEqual(buildAll(chunks.Queries), expected), true)
+
+	// The original query is left as it is
+	assert(t, q.Build(), `SELECT "value" FROM "cpu" WHERE "host" = 'a' AND "time" >= '2020-01-01T00:00:00Z' AND "time" <= '2020-01-01T03:00:00Z'`)
+}
+
+func TestCriteriaTimeEpoch(t *testing.T) {
+	for _, value := range []interface{}{1577836800000000000, int64(1577836800000000000)} {
+		tm, ok := criteriaTime(value)
+		assert(t, ok, true)
+		assert(t, tm, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	}
+}
+
+func TestSplitTimeRangeAligned(t *testing.T) {
+	q := New().
+		Select(`MEAN("value")`).
+		From("cpu").
+		Where("time", ">", "2020-01-01T00:07:00Z").
+		And("time", "<", "2020-01-01T01:00:00Z").
+		GroupByTime(NewDuration().Minute(10)).
+		Desc()
+
+	chunks, err := SplitTimeRange(q, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`SELECT MEAN("value") FROM "cpu" WHERE "time" >= '2020-01-01T00:30:00Z' AND "time" < '2020-01-01T01:00:00Z' GROUP BY time(10m) ORDER BY time DESC`,
+		`SELECT MEAN("value") FROM "cpu" WHERE "time" > '2020-01-01T00:07:00Z' AND "time" < '2020-01-01T00:30:00Z' GROUP BY time(10m) ORDER BY time DESC`,
+	}
+	assert(t, reflect.DeepEqual(buildAll(chunks.Queries), expected), true)
+}
+
+func TestSplitTimeRangeErrors(t *testing.T) {
+	_, err := SplitTimeRange(New().Select("value").From("cpu").Where("time", ">", "2020-01-01T00:00:00Z"), 2)
+	assert(t, err, ErrMissingTimeRange)
+
+	q := New().
+		Select("value").
+		From("cpu").
+		Where("time", ">", "2020-01-01T00:00:00Z").
+		And("time", "<", "2020-01-02T00:00:00Z")
+
+	_, err = SplitTimeRange(q.Limit(10), 2)
+	assert(t, errors.Is(err, ErrUnsupported), true)
+
+	q = New().
+		Select(`MEAN("value")`).
+		From("cpu").
+		Where("time", ">", "2020-01-01T00:00:00Z").
+		And("time", "<", "2020-01-02T00:00:00Z")
+
+	_, err = SplitTimeRange(q, 2)
+	assert(t, errors.Is(err, ErrUnsupported), true)
+
+	q = New().
+		Select("value").
+		From("cpu").
+		Where("time", ">", "2020-01-01T00:00:00Z").
+		Or("time", "<", "2020-01-02T00:00:00Z")
+
+	_, err = SplitTimeRange(q, 2)
+	assert(t, errors.Is(err, ErrUnsupported), true)
+}
+
+func TestChunksExecuteConcatenates(t *testing.T) {
+	q := New().
+		Select("value").
+		From("cpu").
+		Where("time", ">=", "2020-01-01T00:00:00Z").
+		And("time", "<", "2020-01-01T02:00:00Z").
+		GroupByTag("host")
+
+	chunks, err := SplitTimeRange(q, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	executor := &scriptedExecutor{responses: []*Response{
+		{Results: []Result{{Series: []Series{
+			{Name: "cpu", Tags: map[string]string{"host": "a"}, Columns: []string{"time", "value"}, Values: [][]interface{}{{"t1", 1.0}}},
+		}}}},
+		{Results: []Result{{Series: []Series{
+			{Name: "cpu", Tags: map[string]string{"host": "b"}, Columns: []string{"time", "value"}, Values: [][]interface{}{{"t2", 2.0}}},
+			{Name: "cpu", Tags: map[string]string{"host": "a"}, Columns: []string{"time", "value"}, Values: [][]interface{}{{"t3", 3.0}}},
+		}}}},
+	}}
+
+	result, err := chunks.Execute(context.Background(), executor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, len(executor.queries), 2)
+	assert(t, len(result.Series), 2)
+	assert(t, reflect.DeepEqual(result.Series[0].Values, [][]interface{}{{"t1", 1.0}, {"t3", 3.0}}), true)
+	assert(t, reflect.DeepEqual(result.Series[1].Values, [][]interface{}{{"t2", 2.0}}), true)
+}
+
+func TestChunksMergeAggregates(t *testing.T) {
+	q := New().
+		Select(`SUM("value")`, `COUNT("value")`, `MIN("value")`, `MAX("value")`, `FIRST("value")`, `last("value")`).
+		From("cpu").
+		Where("time", ">=", "2020-01-01T00:00:00Z").
+		And("time", "<", "2020-01-01T02:00:00Z")
+
+	chunks, err := SplitTimeRange(q, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns := []string{"time", "sum", "count", "min", "max", "first", "last"}
+	first := []interface{}{"2020-01-01T00:00:00Z", 10.0, int64(4), 1.0, 5.0, 2.0, 3.0}
+	result, err := chunks.Merge([]Result{
+		{Series: []Series{{Name: "cpu", Columns: columns, Values: [][]interface{}{first}}}},
+		{Series: []Series{{Name: "cpu", Columns: columns, Values: [][]interface{}{{"2020-01-01T01:00:00Z", 2.5, int64(2), 0.5, 1.5, 1.0, nil}}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]interface{}{{"2020-01-01T00:00:00Z", 12.5, int64(6), 0.5, 5.0, 2.0, 3.0}}
+	assert(t, reflect.DeepEqual(result.Series[0].Values, expected), true)
+
+	// Inputs are not modified
+	assert(t, first[1], 10.0)
+}
+
+func TestChunksMergeIntegers(t *testing.T) {
+	q := New().
+		Select(`SUM("value")`, `MIN("value")`, `MAX("value")`).
+		From("cpu").
+		Where("time", ">=", "2020-01-01T00:00:00Z").
+		And("time", "<", "2020-01-01T02:00:00Z")
+
+	chunks, err := SplitTimeRange(q, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sums above 2^53 lose precision as float64
+	columns := []string{"time", "sum", "min", "max"}
+	result, err := chunks.Merge([]Result{
+		{Series: []Series{{Name: "cpu", Columns: columns, Values: [][]interface{}{{"t1", json.Number("9007199254740993"), json.Number("9007199254740993"), json.Number("1")}}}}},
+		{Series: []Series{{Name: "cpu", Columns: columns, Values: [][]interface{}{{"t2", json.Number("2"), json.Number("9007199254740992"), json.Number("2")}}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]interface{}{{"t1", int64(9007199254740995), json.Number("9007199254740992"), json.Number("2")}}
+	assert(t, reflect.DeepEqual(result.Series[0].Values, expected), true)
+
+	result, err = chunks.Merge([]Result{
+		{Series: []Series{{Name: "cpu", Columns: columns, Values: [][]interface{}{{"t1", json.Number("1"), nil, nil}}}}},
+		{Series: []Series{{Name: "cpu", Columns: columns, Values: [][]interface{}{{"t2", json.Number("0.5"), nil, nil}}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, result.Series[0].Values[0][1], 1.5)
+}
+
+func TestChunksExecuteError(t *testing.T) {
+	q := New().
+		Select("value").
+		From("cpu").
+		Where("time", ">=", "2020-01-01T00:00:00Z").
+		And("time", "<", "2020-01-01T02:00:00Z")
+
+	chunks, err := SplitTimeRange(q, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	executor := &scriptedExecutor{responses: []*Response{{Results: []Result{{Err: "timeout"}}}}}
+
+	_, err = chunks.Execute(context.Background(), executor)
+	assert(t, err.Error(), "timeout")
+	assert(t, len(executor.queries), 1)
+}
@@ -0,0 +1,132 @@
+package influxquerybuilder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Client Executor running queries against the /query endpoint of InfluxDB 1.x
+type Client struct {
+	// URL Base URL, e.g. http://localhost:8086
+	URL             string
+	Database        string
+	RetentionPolicy string
+	// Epoch Precision of returned times, RFC3339 strings when empty
+	Epoch    string
+	Username string
+	Password string
+	// Format Response format of Execute, JSON when empty
+	Format Format
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// WithFormat Copy of the client requesting responses in the given format
+func (c *Client) WithFormat(format Format) *Client {
+	n := *c
+	n.Format = format
+	return &n
+}
+
+// Execute Execute a query and decode the whole response
+func (c *Client) Execute(ctx context.Context, query string) (*Response, error) {
+	format := c.Format
+	if format == "" {
+		format = JSONFormat
+	}
+
+	resp, err := c.post(ctx, query, nil, format)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Servers that do not label the body answer in the requested format
+	contentType := resp.Header.Get("Content-Type")
+	if _, ok := formatOf(contentType); !ok {
+		contentType = string(format)
+	}
+
+	return DecodeResponse(resp.Body, contentType)
+}
+
+// Stream Execute a query with chunked=true and decode the response chunk by
+// chunk, chunkSize 0 keeps the server default. The decoder must be closed
+func (c *Client) Stream(ctx context.Context, query string, chunkSize int) (*StreamDecoder, error) {
+	params := url.Values{"chunked": {"true"}}
+	if chunkSize > 0 {
+		params.Set("chunk_size", strconv.Itoa(chunkSize))
+	}
+
+	resp, err := c.post(ctx, query, params, JSONFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStreamDecoder(resp.Body), nil
+}
+
+func (c *Client) post(ctx context.Context, query string, params url.Values, format Format) (*http.Response, error) {
+	form := url.Values{"q": {query}}
+
+	for k, v := range map[string]string{"db": c.Database, "rp": c.RetentionPolicy, "epoch": c.Epoch} {
+		if v != "" {
+			form.Set(k, v)
+		}
+	}
+
+	for k, v := range params {
+		form[k] = v
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		strings.TrimSuffix(c.URL, "/")+"/query",
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", string(format))
+
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, statusError(resp)
+	}
+
+	return resp, nil
+}
+
+// statusError error of a non 2xx response, with the error message of the body
+// when there is one
+func statusError(resp *http.Response) error {
+	var body Response
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body); err == nil && body.Err != "" {
+		return fmt.Errorf("influxquerybuilder: %s: %s", resp.Status, body.Err)
+	}
+
+	return fmt.Errorf("influxquerybuilder: %s", resp.Status)
+}
@@ -0,0 +1,90 @@
+package influxquerybuilder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientExecute(t *testing.T) {
+	var form map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = map[string]string{}
+		for k := range r.Form {
+			form[k] = r.Form.Get(k)
+		}
+
+		user, password, _ := r.BasicAuth()
+		form["user"] = user + ":" + password
+		form["method"] = r.Method
+
+		w.Write([]byte(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[[1577836800000000000,1]]}]}]}`))
+	}))
+	defer server.Close()
+
+	client := &Client{URL: server.URL + "/", Database: "telegraf", Epoch: "ns", Username: "admin", Password: "secret"}
+	query := New().Select("value").From("cpu").Build()
+
+	resp, err := client.Execute(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, form["q"], query)
+	assert(t, form["db"], "telegraf")
+	assert(t, form["epoch"], "ns")
+	assert(t, form["user"], "admin:secret")
+	assert(t, form["method"], http.MethodPost)
+	assert(t, resp.Results[0].Series[0].Values[0][0], json.Number("1577836800000000000"))
+}
+
+func TestClientStream(t *testing.T) {
+	var chunked, chunkSize string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chunked, chunkSize = r.FormValue("chunked"), r.FormValue("chunk_size")
+		w.Write([]byte(chunkedResponse))
+	}))
+	defer server.Close()
+
+	client := &Client{URL: server.URL, Database: "telegraf"}
+
+	decoder, err := client.Stream(context.Background(), `SELECT "value" FROM "cpu"`, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer decoder.Close()
+
+	rows := 0
+	for _, err := range decoder.Rows(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows++
+	}
+
+	assert(t, chunked, "true")
+	assert(t, chunkSize, "2")
+	assert(t, rows, 4)
+}
+
+func TestClientStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("db") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"database name required"}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := (&Client{URL: server.URL}).Execute(context.Background(), "SHOW MEASUREMENTS")
+	assert(t, err.Error(), "influxquerybuilder: 400 Bad Request: database name required")
+
+	_, err = (&Client{URL: server.URL, Database: "db"}).Stream(context.Background(), "SHOW MEASUREMENTS", 0)
+	assert(t, err.Error(), "influxquerybuilder: 502 Bad Gateway")
+}
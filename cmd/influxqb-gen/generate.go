@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+// reserved identifiers declared by every generated package
+var reserved = map[string]bool{
+	"Measurement": true, "Key": true, "Criteria": true, "Query": true, "Select": true, "New": true,
+	"TagKey": true, "FloatField": true, "IntegerField": true, "StringField": true, "BooleanField": true,
+}
+
+// fieldTypes maps schema field types to generated Go types
+var fieldTypes = map[qb.KeyType]string{
+	qb.FloatType:   "FloatField",
+	qb.IntegerType: "IntegerField",
+	qb.StringType:  "StringField",
+	qb.BooleanType: "BooleanField",
+}
+
+type key struct {
+	Ident string
+	Type  string
+	Name  string
+}
+
+type measurement struct {
+	Package string
+	Name    string
+	Fields  []key
+	Tags    []key
+}
+
+// generate generates the source of one package per measurement, by package
+// name. Measurements mapping to the same package name are an error
+func generate(schema *qb.Schema) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	measurements := map[string]string{}
+
+	names := make([]string, 0, len(schema.Measurements))
+	for name := range schema.Measurements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		m := schema.Measurements[name]
+		data := measurement{Package: packageName(name), Name: name}
+
+		if other, ok := measurements[data.Package]; ok {
+			return nil, fmt.Errorf("measurements %q and %q both map to package %s", other, name, data.Package)
+		}
+		measurements[data.Package] = name
+		used := map[string]bool{}
+
+		for _, tag := range sortedTags(m.Tags) {
+			data.Tags = append(data.Tags, key{Ident: identifier(tag, "Tag", used), Type: "TagKey", Name: tag})
+		}
+
+		for _, field := range sortedFields(m.Fields) {
+			t, ok := fieldTypes[m.Fields[field]]
+			if !ok {
+				return nil, fmt.Errorf("measurement %q: field %q has unknown type %q", name, field, m.Fields[field])
+			}
+
+			data.Fields = append(data.Fields, key{Ident: identifier(field, "Field", used), Type: t, Name: field})
+		}
+
+		var buffer bytes.Buffer
+		if err := packageTemplate.Execute(&buffer, data); err != nil {
+			return nil, err
+		}
+
+		src, err := format.Source(buffer.Bytes())
+		if err != nil {
+			return nil, err
+		}
+
+		files[data.Package] = src
+	}
+
+	return files, nil
+}
+
+// identifier converts a key such as usage_idle into UsageIdle, adding suffix
+// when it clashes with a reserved or already used identifier
+func identifier(name string, suffix string, used map[string]bool) string {
+	var buffer bytes.Buffer
+	upper := true
+
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+
+		buffer.WriteRune(r)
+	}
+
+	ident := buffer.String()
+	if ident == "" || unicode.IsDigit(rune(ident[0])) {
+		ident = "K" + ident
+	}
+
+	for reserved[ident] || used[ident] {
+		ident += suffix
+	}
+
+	used[ident] = true
+
+	return ident
+}
+
+// packageName converts a measurement name into a Go package name, prefixing
+// names that are not valid or importable package names with m
+func packageName(name string) string {
+	var buffer bytes.Buffer
+
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			buffer.WriteRune(r)
+		}
+	}
+
+	pkg := buffer.String()
+	if pkg == "" || unicode.IsDigit(rune(pkg[0])) || token.IsKeyword(pkg) || pkg == "main" {
+		pkg = "m" + pkg
+	}
+
+	return pkg
+}
+
+func sortedTags(tags []string) []string {
+	seen := map[string]bool{}
+	sorted := []string{}
+
+	for _, t := range tags {
+		if !seen[t] {
+			seen[t] = true
+			sorted = append(sorted, t)
+		}
+	}
+
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+func sortedFields(fields map[string]qb.KeyType) []string {
+	sorted := make([]string, 0, len(fields))
+
+	for f := range fields {
+		sorted = append(sorted, f)
+	}
+
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+var packageTemplate = template.Must(template.New("package").Parse(`// Code generated by influxqb-gen. DO NOT EDIT.
+
+// Package {{.Package}} Typed queries of the {{printf "%q" .Name}} measurement
+package {{.Package}}
+
+import (
+	"regexp"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+// Measurement Measurement name
+const Measurement = {{printf "%q" .Name}}
+
+// Key Tag or field key of the measurement
+type Key interface {
+	key() string
+}
+
+// TagKey Tag key
+type TagKey string
+
+// FloatField Float field key
+type FloatField string
+
+// IntegerField Integer field key
+type IntegerField string
+
+// StringField String field key
+type StringField string
+
+// BooleanField Boolean field key
+type BooleanField string
+
+// Tags
+{{- if .Tags}}
+const (
+{{- range .Tags}}
+	{{.Ident}} {{.Type}} = {{printf "%q" .Name}}
+{{- end}}
+)
+{{- end}}
+
+// Fields
+{{- if .Fields}}
+const (
+{{- range .Fields}}
+	{{.Ident}} {{.Type}} = {{printf "%q" .Name}}
+{{- end}}
+)
+{{- end}}
+
+// key casts keep a tag and a field of the same name apart
+func (k TagKey) key() string       { return qb.TagKey(string(k)) }
+func (k FloatField) key() string   { return qb.FieldKey(string(k)) }
+func (k IntegerField) key() string { return qb.FieldKey(string(k)) }
+func (k StringField) key() string  { return qb.FieldKey(string(k)) }
+func (k BooleanField) key() string { return qb.FieldKey(string(k)) }
+
+// Criteria Criteria on a key of the measurement
+type Criteria struct {
+	key   string
+	op    qb.Operator
+	value interface{}
+}
+
+// Eq = value
+func (k TagKey) Eq(value string) Criteria { return Criteria{k.key(), qb.Eq, value} }
+
+// Neq != value
+func (k TagKey) Neq(value string) Criteria { return Criteria{k.key(), qb.Neq, value} }
+
+// Match =~ /re/
+func (k TagKey) Match(re *regexp.Regexp) Criteria { return Criteria{k.key(), qb.Match, re} }
+
+// NotMatch !~ /re/
+func (k TagKey) NotMatch(re *regexp.Regexp) Criteria { return Criteria{k.key(), qb.NotMatch, re} }
+
+// Eq = value
+func (k FloatField) Eq(value float64) Criteria { return Criteria{k.key(), qb.Eq, value} }
+
+// Neq != value
+func (k FloatField) Neq(value float64) Criteria { return Criteria{k.key(), qb.Neq, value} }
+
+// Lt < value
+func (k FloatField) Lt(value float64) Criteria { return Criteria{k.key(), qb.Lt, value} }
+
+// Lte <= value
+func (k FloatField) Lte(value float64) Criteria { return Criteria{k.key(), qb.Lte, value} }
+
+// Gt > value
+func (k FloatField) Gt(value float64) Criteria { return Criteria{k.key(), qb.Gt, value} }
+
+// Gte >= value
+func (k FloatField) Gte(value float64) Criteria { return Criteria{k.key(), qb.Gte, value} }
+
+// Eq = value
+func (k IntegerField) Eq(value int64) Criteria { return Criteria{k.key(), qb.Eq, value} }
+
+// Neq != value
+func (k IntegerField) Neq(value int64) Criteria { return Criteria{k.key(), qb.Neq, value} }
+
+// Lt < value
+func (k IntegerField) Lt(value int64) Criteria { return Criteria{k.key(), qb.Lt, value} }
+
+// Lte <= value
+func (k IntegerField) Lte(value int64) Criteria { return Criteria{k.key(), qb.Lte, value} }
+
+// Gt > value
+func (k IntegerField) Gt(value int64) Criteria { return Criteria{k.key(), qb.Gt, value} }
+
+// Gte >= value
+func (k IntegerField) Gte(value int64) Criteria { return Criteria{k.key(), qb.Gte, value} }
+
+// Eq = value
+func (k StringField) Eq(value string) Criteria { return Criteria{k.key(), qb.Eq, value} }
+
+// Neq != value
+func (k StringField) Neq(value string) Criteria { return Criteria{k.key(), qb.Neq, value} }
+
+// Match =~ /re/
+func (k StringField) Match(re *regexp.Regexp) Criteria { return Criteria{k.key(), qb.Match, re} }
+
+// NotMatch !~ /re/
+func (k StringField) NotMatch(re *regexp.Regexp) Criteria { return Criteria{k.key(), qb.NotMatch, re} }
+
+// Eq = value
+func (k BooleanField) Eq(value bool) Criteria { return Criteria{k.key(), qb.Eq, value} }
+
+// Neq != value
+func (k BooleanField) Neq(value bool) Criteria { return Criteria{k.key(), qb.Neq, value} }
+
+// Query Typed query of the measurement, untyped methods of the embedded
+// QueryBuilder remain available
+type Query struct {
+	qb.QueryBuilder
+}
+
+// New New Query from the measurement
+func New() *Query {
+	return &Query{qb.New().From(Measurement)}
+}
+
+// Select SELECT keys FROM the measurement
+func Select(keys ...Key) *Query {
+	return New().Select(keys...)
+}
+
+// Select Select keys
+func (q *Query) Select(keys ...Key) *Query {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.key()
+	}
+
+	q.QueryBuilder.Select(names...)
+	return q
+}
+
+// Where Where criteria
+func (q *Query) Where(c Criteria) *Query {
+	q.QueryBuilder.Where(c.key, string(c.op), c.value)
+	return q
+}
+
+// And And criteria
+func (q *Query) And(c Criteria) *Query {
+	q.QueryBuilder.And(c.key, string(c.op), c.value)
+	return q
+}
+
+// Or Or criteria
+func (q *Query) Or(c Criteria) *Query {
+	q.QueryBuilder.Or(c.key, string(c.op), c.value)
+	return q
+}
+
+// GroupByTag GROUP BY tags
+func (q *Query) GroupByTag(tags ...TagKey) *Query {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = string(t)
+	}
+
+	q.QueryBuilder.GroupByTag(names...)
+	return q
+}
+`))
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+func TestGenerate(t *testing.T) {
+	schema := qb.NewSchema().
+		AddMeasurement("cpu", []string{"host", "select"}, map[string]qb.KeyType{
+			"usage_idle": qb.FloatType,
+			"cores":      qb.IntegerType,
+			"host":       qb.StringType,
+		}).
+		AddMeasurement("disk-io", nil, map[string]qb.KeyType{"1m": qb.FloatType}).
+		AddMeasurement("type", []string{"host"}, nil)
+
+	files, err := generate(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 packages but got %d", len(files))
+	}
+
+	src := string(files["cpu"])
+	for _, expected := range []string{
+		"package cpu",
+		`const Measurement = "cpu"`,
+		`Host      TagKey = "host"`,
+		`SelectTag TagKey = "select"`,
+		`Cores     IntegerField = "cores"`,
+		`HostField StringField  = "host"`,
+		`UsageIdle FloatField   = "usage_idle"`,
+	} {
+		if !strings.Contains(src, expected) {
+			t.Errorf("Expected %s in\n%s", expected, src)
+		}
+	}
+
+	if !strings.Contains(string(files["diskio"]), `K1m FloatField = "1m"`) {
+		t.Errorf("Unexpected disk-io package\n%s", files["diskio"])
+	}
+
+	if !strings.Contains(string(files["mtype"]), "package mtype") {
+		t.Errorf("Unexpected type package\n%s", files["mtype"])
+	}
+
+	for pkg, src := range files {
+		if _, err := parser.ParseFile(token.NewFileSet(), pkg+".go", src, 0); err != nil {
+			t.Errorf("Generated %s does not parse: %s", pkg, err)
+		}
+	}
+}
+
+func TestGenerateCollision(t *testing.T) {
+	schema := qb.NewSchema().
+		AddMeasurement("disk-io", []string{"host"}, nil).
+		AddMeasurement("diskio", []string{"host"}, nil)
+
+	_, err := generate(schema)
+	if err == nil || err.Error() != `measurements "disk-io" and "diskio" both map to package diskio` {
+		t.Errorf("Expected a collision error but got %v", err)
+	}
+}
+
+func TestGenerateUnknownType(t *testing.T) {
+	schema := qb.NewSchema().AddMeasurement("cpu", nil, map[string]qb.KeyType{"usage": "unsigned"})
+
+	_, err := generate(schema)
+	if err == nil || err.Error() != `measurement "cpu": field "usage" has unknown type "unsigned"` {
+		t.Errorf("Expected an unknown type error but got %v", err)
+	}
+}
+
+// TestGenerateCompile builds the generated packages in a module that uses
+// them and checks the queries they build
+func TestGenerateCompile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the go command")
+	}
+
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	schema := qb.NewSchema().
+		AddMeasurement("cpu", []string{"host"}, map[string]qb.KeyType{"usage_idle": qb.FloatType, "host": qb.StringType}).
+		AddMeasurement("type", []string{"host"}, nil)
+
+	files, err := generate(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := map[string]string{
+		"go.mod": "module gen\n\ngo 1.23\n\nrequire github.com/benjamin658/influx-query-builder v0.0.0\n\n" +
+			"replace github.com/benjamin658/influx-query-builder => " + root + "\n",
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"gen/cpu"
+	"gen/mtype"
+)
+
+func main() {
+	fmt.Println(cpu.Select(cpu.UsageIdle, cpu.Host, cpu.HostField).Where(cpu.Host.Eq("a")).And(cpu.UsageIdle.Gt(10)).Build())
+	fmt.Println(mtype.Select(mtype.Host).Build())
+}
+`,
+	}
+	for pkg, src := range files {
+		sources[filepath.Join(pkg, pkg+".go")] = string(src)
+	}
+
+	for name, src := range sources {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, args := range [][]string{{"vet", "./..."}, {"run", "."}} {
+		cmd := exec.Command(goCmd, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off", "GOPROXY=off")
+
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("go %s: %s\n%s", args[0], err, out)
+		}
+
+		if args[0] == "run" {
+			expected := `SELECT "usage_idle"::field,"host"::tag,"host"::field FROM "cpu" WHERE "host"::tag = 'a' AND "usage_idle"::field > 10` + "\n" +
+				`SELECT "host"::tag FROM "type"` + "\n"
+			if string(out) != expected {
+				t.Errorf("Expected\n%s\nbut got\n%s", expected, out)
+			}
+		}
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	used := map[string]bool{}
+
+	if ident := identifier("usage_idle", "Field", used); ident != "UsageIdle" {
+		t.Errorf("Expected UsageIdle but got %s", ident)
+	}
+
+	if ident := identifier("usage-idle", "Field", used); ident != "UsageIdleField" {
+		t.Errorf("Expected UsageIdleField but got %s", ident)
+	}
+
+	if ident := identifier("measurement", "Tag", used); ident != "MeasurementTag" {
+		t.Errorf("Expected MeasurementTag but got %s", ident)
+	}
+}
@@ -0,0 +1,55 @@
+// Command influxqb-gen generates typed query packages from a JSON or YAML schema.
+//
+// Each measurement of the schema becomes a package with constants for its
+// tag and field keys and a typed wrapper around New().From(...):
+//
+//	//go:generate go run github.com/benjamin658/influx-query-builder/cmd/influxqb-gen -schema schema.json -out measurements
+//
+//	cpu.Select(cpu.UsageIdle).Where(cpu.Host.Eq("a")).Build()
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "schema.json", "JSON or YAML schema file")
+	out := flag.String("out", ".", "output directory, one package per measurement")
+	flag.Parse()
+
+	if err := run(*schemaPath, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "influxqb-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(schemaPath, out string) error {
+	schema, err := qb.LoadSchemaFile(schemaPath)
+	if err != nil {
+		return err
+	}
+
+	files, err := generate(schema)
+	if err != nil {
+		return err
+	}
+
+	for pkg, src := range files {
+		dir := filepath.Join(out, pkg)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(dir, pkg+".go"), src, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+// Command influxqb builds, formats, converts and lints InfluxQL.
+//
+// Usage:
+//
+//	influxqb [flags] fmt|pretty|flux|sql|lint [file ...]
+//
+// Each file holds one SELECT statement, or a JSON QuerySpec with -json.
+// Without files the query is read from stdin. The exit code is 0 on success,
+// 1 when a query is invalid and 2 on usage errors.
+//
+// lint prints the findings of the query linter as "file: severity rule: message"
+// and fails queries with findings of severity error.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+const (
+	exitOK      = 0
+	exitInvalid = 1
+	exitUsage   = 2
+)
+
+// errLint Error of queries with lint findings of severity error
+var errLint = errors.New("lint errors")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("influxqb", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	jsonInput := flags.Bool("json", false, "read JSON query specs instead of InfluxQL")
+	bucket := flags.String("bucket", "", "bucket of the flux command")
+	schemaPath := flags.String("schema", "", "JSON or YAML schema checked by the lint command")
+	lowercase := flags.Bool("lowercase", false, "lowercase keywords of the pretty command")
+	disable := flags.String("disable", "", "comma separated lint rules to disable")
+	highCardinality := flags.String("high-cardinality", "", "comma separated high cardinality tags of the lint command")
+	maxOffset := flags.Uint("max-offset", qb.DefaultMaxOffset, "largest OFFSET of the lint command")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: influxqb [flags] fmt|pretty|flux|sql|lint [file ...]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	command := flags.Arg(0)
+	var schema *qb.Schema
+
+	switch command {
+	case "fmt", "pretty", "sql", "lint":
+	case "flux":
+		if *bucket == "" {
+			fmt.Fprintln(stderr, "influxqb: flux needs -bucket")
+			return exitUsage
+		}
+	default:
+		fmt.Fprintf(stderr, "influxqb: unknown command %s\n", command)
+		flags.Usage()
+		return exitUsage
+	}
+
+	if *schemaPath != "" {
+		var err error
+
+		schema, err = qb.LoadSchemaFile(*schemaPath)
+		if err != nil {
+			fmt.Fprintln(stderr, "influxqb: schema:", err)
+			return exitUsage
+		}
+	}
+
+	linter := qb.Linter{MaxOffset: *maxOffset, HighCardinalityTags: splitList(*highCardinality)}
+
+	for _, rule := range splitList(*disable) {
+		if !knownRule(rule) {
+			fmt.Fprintf(stderr, "influxqb: unknown lint rule %s\n", rule)
+			return exitUsage
+		}
+
+		linter.Disabled = append(linter.Disabled, rule)
+	}
+
+	inputs := flags.Args()[1:]
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+
+	code := exitOK
+
+	for _, name := range inputs {
+		src, err := readInput(name, stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, "influxqb:", err)
+			return exitUsage
+		}
+
+		opts := qb.PrettyOptions{}
+		if *lowercase {
+			opts.Keywords = qb.LowerKeywords
+		}
+
+		out, err := process(command, src, *jsonInput, *bucket, opts, schema, linter)
+
+		if command == "lint" && out != "" {
+			out = displayName(name) + ": " + strings.ReplaceAll(out, "\n", "\n"+displayName(name)+": ")
+		}
+
+		if out != "" {
+			fmt.Fprintln(stdout, out)
+		}
+
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", displayName(name), err)
+			code = exitInvalid
+		}
+	}
+
+	return code
+}
+
+func readInput(name string, stdin io.Reader) ([]byte, error) {
+	if name == "-" {
+		return ioutil.ReadAll(stdin)
+	}
+
+	return ioutil.ReadFile(name)
+}
+
+func displayName(name string) string {
+	if name == "-" {
+		return "<stdin>"
+	}
+
+	return name
+}
+
+func process(command string, src []byte, jsonInput bool, bucket string, opts qb.PrettyOptions, schema *qb.Schema, linter qb.Linter) (string, error) {
+	var q qb.QueryBuilder
+	var err error
+
+	if jsonInput {
+		q, err = decodeSpec(src)
+	} else {
+		q, err = qb.Parse(string(src))
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := q.Validate(); err != nil {
+		return "", err
+	}
+
+	switch command {
+	case "fmt":
+		return q.Build(), nil
+	case "pretty":
+		return q.BuildPretty(opts), nil
+	case "flux":
+		return q.BuildFlux(bucket)
+	case "sql":
+		return q.BuildSQL()
+	case "lint":
+		if schema != nil {
+			if err := q.ValidateAgainst(schema); err != nil {
+				return "", err
+			}
+		}
+
+		return lint(q, linter)
+	}
+
+	return "", nil
+}
+
+// lint findings of a query, one per line, errLint with errors among them
+func lint(q qb.QueryBuilder, linter qb.Linter) (string, error) {
+	var lines []string
+	var err error
+
+	for _, f := range linter.Lint(q) {
+		lines = append(lines, f.String())
+
+		if f.Severity == qb.SeverityError {
+			err = errLint
+		}
+	}
+
+	return strings.Join(lines, "\n"), err
+}
+
+func knownRule(id string) bool {
+	for _, rule := range qb.LintRules {
+		if rule.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitList(s string) []string {
+	var values []string
+
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+func decodeSpec(src []byte) (qb.QueryBuilder, error) {
+	var spec qb.QuerySpec
+
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&spec); err != nil {
+		return nil, err
+	}
+
+	return qb.NewFromSpec(spec)
+}
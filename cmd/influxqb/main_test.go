@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runCommand(t *testing.T, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+
+	return code, strings.TrimSpace(stdout.String()), strings.TrimSpace(stderr.String())
+}
+
+func TestFmt(t *testing.T) {
+	code, out, _ := runCommand(t, "select temperature from measurement where host = 'a' limit 1", "fmt")
+
+	if code != exitOK || out != `SELECT "temperature" FROM "measurement" WHERE "host" = 'a' LIMIT 1` {
+		t.Errorf("Unexpected %d %s", code, out)
+	}
+}
+
+func TestPretty(t *testing.T) {
+	expected := `SELECT MEAN("t")
+FROM "m"
+WHERE "time" > '2018-11-01T00:00:00Z'
+  AND (
+    "host" =~ /a b/
+    OR "x" = 'AND (z)'
+  )
+GROUP BY time(10m)
+FILL(none)
+LIMIT 3`
+	query := `SELECT MEAN("t") FROM "m" WHERE "time" > '2018-11-01T00:00:00Z' AND ("host" =~ /a b/ OR "x" = 'AND (z)') GROUP BY time(10m) FILL(none) LIMIT 3`
+	code, out, _ := runCommand(t, query, "pretty")
+
+	if code != exitOK || out != expected {
+		t.Errorf("Unexpected %d\n%s", code, out)
+	}
+
+	code, out, _ = runCommand(t, `SELECT "t" FROM "m" LIMIT 3`, "-lowercase", "pretty")
+
+	if code != exitOK || out != "select \"t\"\nfrom \"m\"\nlimit 3" {
+		t.Errorf("Unexpected %d\n%s", code, out)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	query := `SELECT MEAN("t") FROM "m" GROUP BY time(10m) FILL(none)`
+
+	code, out, _ := runCommand(t, query, "-bucket", "db/rp", "flux")
+	if code != exitOK || !strings.Contains(out, "aggregateWindow(every: 10m, fn: mean, createEmpty: false)") {
+		t.Errorf("Unexpected %d\n%s", code, out)
+	}
+
+	code, out, _ = runCommand(t, query, "sql")
+	if code != exitOK || out != `SELECT date_bin(INTERVAL '10 minutes', time) AS time, avg("t") AS "mean" FROM "m" GROUP BY 1 ORDER BY time` {
+		t.Errorf("Unexpected %d\n%s", code, out)
+	}
+
+	code, _, errOut := runCommand(t, `SELECT TOP("t", 3) FROM "m"`, "sql")
+	if code != exitInvalid || !strings.Contains(errOut, "<stdin>: ") {
+		t.Errorf("Unexpected %d %s", code, errOut)
+	}
+}
+
+func TestJSONInput(t *testing.T) {
+	spec := `{
+		"version": 1,
+		"fields": ["temperature"],
+		"measurement": "measurement",
+		"criteria": {
+			"where": {"key": "host", "op": "=", "value": "a"},
+			"or": [{"key": "cpu", "op": ">", "value": 1}]
+		},
+		"groupByTags": ["host"],
+		"order": "DESC",
+		"limit": 10
+	}`
+	code, out, errOut := runCommand(t, spec, "-json", "fmt")
+
+	if code != exitOK || out != `SELECT "temperature" FROM "measurement" WHERE "host" = 'a' OR "cpu" > 1 GROUP BY host ORDER BY time DESC LIMIT 10` {
+		t.Errorf("Unexpected %d %s %s", code, out, errOut)
+	}
+}
+
+func TestLint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "influxqb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	schema := filepath.Join(dir, "schema.json")
+	good := filepath.Join(dir, "good.influxql")
+	bad := filepath.Join(dir, "bad.influxql")
+	ioutil.WriteFile(schema, []byte(`{"measurements": {"m": {"tags": ["host"], "fields": {"v": "float"}}}}`), 0644)
+	ioutil.WriteFile(good, []byte(`SELECT "v" FROM "m" WHERE "host" = 'a'`), 0644)
+	ioutil.WriteFile(bad, []byte(`SELECT "x" FROM "m" WHERE "v" => 1`), 0644)
+
+	code, _, errOut := runCommand(t, "", "-schema", schema, "lint", good)
+	if code != exitOK || errOut != "" {
+		t.Errorf("Unexpected %d %s", code, errOut)
+	}
+
+	code, _, errOut = runCommand(t, "", "-schema", schema, "lint", good, bad)
+	if code != exitInvalid || !strings.Contains(errOut, "bad.influxql: ") || strings.Contains(errOut, "good.influxql") {
+		t.Errorf("Unexpected %d %s", code, errOut)
+	}
+
+	code, _, errOut = runCommand(t, `SELECT "v" FROM "m" WHERE "hots" = 'a'`, "-schema", schema, "lint")
+	if code != exitInvalid || !strings.Contains(errOut, "<stdin>: influxquerybuilder: hots: unknown key") {
+		t.Errorf("Unexpected %d %s", code, errOut)
+	}
+
+	yamlSchema := filepath.Join(dir, "schema.yaml")
+	ioutil.WriteFile(yamlSchema, []byte("measurements:\n  m:\n    tags: [host]\n    fields: {v: float}\n"), 0644)
+
+	code, _, errOut = runCommand(t, `SELECT "v" FROM "m" WHERE "hots" = 'a'`, "-schema", yamlSchema, "lint")
+	if code != exitInvalid || !strings.Contains(errOut, "<stdin>: influxquerybuilder: hots: unknown key") {
+		t.Errorf("Unexpected %d %s", code, errOut)
+	}
+}
+
+func TestLintFindings(t *testing.T) {
+	query := `SELECT * FROM "m" WHERE "host" =~ /^web/ GROUP BY * LIMIT 10`
+
+	code, out, errOut := runCommand(t, query, "-high-cardinality", "host", "lint")
+	expected := `<stdin>: warning missing-time-range: query of "m" has no lower time bound
+<stdin>: warning select-all-group-all: SELECT * with GROUP BY *
+<stdin>: warning regex-high-cardinality: regex /^web/ on high cardinality tag "host"
+<stdin>: info limit-without-order: LIMIT 10 without ORDER BY time`
+
+	if code != exitOK || out != expected || errOut != "" {
+		t.Errorf("Unexpected %d\n%s\n%s", code, out, errOut)
+	}
+
+	code, out, _ = runCommand(t, query, "-disable", "missing-time-range, limit-without-order", "lint")
+	if code != exitOK || out != "<stdin>: warning select-all-group-all: SELECT * with GROUP BY *" {
+		t.Errorf("Unexpected %d\n%s", code, out)
+	}
+
+	code, out, errOut = runCommand(t, `SELECT "v" FROM "m" WHERE time > '2018-11-01T00:00:00Z' FILL(linear) OFFSET 20`, "-max-offset", "10", "lint")
+	if code != exitInvalid || !strings.Contains(out, "large-offset") || !strings.Contains(out, "error fill-linear-without-time") || errOut != "<stdin>: lint errors" {
+		t.Errorf("Unexpected %d\n%s\n%s", code, out, errOut)
+	}
+
+	if code, _, _ := runCommand(t, query, "-disable", "no-such-rule", "lint"); code != exitUsage {
+		t.Errorf("Expected usage exit code but got %d", code)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	if code, _, _ := runCommand(t, ""); code != exitUsage {
+		t.Errorf("Expected usage exit code but got %d", code)
+	}
+
+	if code, _, _ := runCommand(t, "", "explode"); code != exitUsage {
+		t.Errorf("Expected usage exit code but got %d", code)
+	}
+
+	if code, _, _ := runCommand(t, "", "flux"); code != exitUsage {
+		t.Errorf("Expected usage exit code but got %d", code)
+	}
+
+	if code, _, _ := runCommand(t, "", "fmt", "/does/not/exist"); code != exitUsage {
+		t.Errorf("Expected usage exit code but got %d", code)
+	}
+}
@@ -0,0 +1,140 @@
+package influxquerybuilder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrMissingQuery A continuous query has no SELECT body
+	ErrMissingQuery = errors.New("influxquerybuilder: continuous query needs a query")
+	// ErrMissingInto The continuous query body has no INTO clause
+	ErrMissingInto = errors.New("influxquerybuilder: continuous query body needs INTO")
+	// ErrMissingGroupByTime The continuous query body has no GROUP BY time clause
+	ErrMissingGroupByTime = errors.New("influxquerybuilder: continuous query body needs GROUP BY time")
+)
+
+// ContinuousQueryBuilder ContinuousQueryBuilder interface
+type ContinuousQueryBuilder interface {
+	ResampleEvery(Duration) ContinuousQueryBuilder
+	ResampleFor(Duration) ContinuousQueryBuilder
+	Query(QueryBuilder) ContinuousQueryBuilder
+	Statement
+}
+
+// ContinuousQuery ContinuousQuery struct
+type ContinuousQuery struct {
+	name     string
+	database string
+	every    Duration
+	_for     Duration
+	query    QueryBuilder
+}
+
+// CreateContinuousQuery CREATE CONTINUOUS QUERY "name" ON "database"
+func CreateContinuousQuery(name, database string) ContinuousQueryBuilder {
+	return &ContinuousQuery{name: name, database: database}
+}
+
+// DropContinuousQuery DROP CONTINUOUS QUERY "name" ON "database"
+func DropContinuousQuery(name, database string) Statement {
+	return &dropStatement{kind: "CONTINUOUS QUERY", name: name, database: database, on: true}
+}
+
+// ShowContinuousQueries SHOW CONTINUOUS QUERIES
+func ShowContinuousQueries() Statement {
+	return &showStatement{what: "CONTINUOUS QUERIES"}
+}
+
+// ResampleEvery RESAMPLE EVERY x
+func (c *ContinuousQuery) ResampleEvery(every Duration) ContinuousQueryBuilder {
+	c.every = every
+	return c
+}
+
+// ResampleFor RESAMPLE FOR x
+func (c *ContinuousQuery) ResampleFor(duration Duration) ContinuousQueryBuilder {
+	c._for = duration
+	return c
+}
+
+// Query BEGIN <query> END
+func (c *ContinuousQuery) Query(query QueryBuilder) ContinuousQueryBuilder {
+	c.query = query
+	return c
+}
+
+// Validate Validate the statement
+func (c *ContinuousQuery) Validate() error {
+	if err := validateName("continuous query", c.name); err != nil {
+		return err
+	}
+
+	if err := validateName("database", c.database); err != nil {
+		return err
+	}
+
+	if c.every != nil {
+		if err := validateDuration("resample every", c.every, false); err != nil {
+			return err
+		}
+	}
+
+	if c._for != nil {
+		if err := validateDuration("resample for", c._for, false); err != nil {
+			return err
+		}
+	}
+
+	if c.query == nil {
+		return ErrMissingQuery
+	}
+
+	if err := c.query.Validate(); err != nil {
+		return fmt.Errorf("continuous query body: %w", err)
+	}
+
+	body := c.query.GetQueryStruct()
+
+	if body.Into == "" {
+		return ErrMissingInto
+	}
+
+	if body.GroupBy == "" {
+		return ErrMissingGroupByTime
+	}
+
+	return nil
+}
+
+// Build Build statement string, empty if the statement is invalid
+func (c *ContinuousQuery) Build() string {
+	if c.Validate() != nil {
+		return ""
+	}
+
+	var buffer bytes.Buffer
+
+	buffer.WriteString(fmt.Sprintf(
+		"CREATE CONTINUOUS QUERY %s ON %s ",
+		quoteIdent(c.name),
+		quoteIdent(c.database),
+	))
+
+	if c.every != nil || c._for != nil {
+		buffer.WriteString("RESAMPLE ")
+
+		if c.every != nil {
+			buffer.WriteString(fmt.Sprintf("EVERY %s ", c.every.getLiteral()))
+		}
+
+		if c._for != nil {
+			buffer.WriteString(fmt.Sprintf("FOR %s ", c._for.getLiteral()))
+		}
+	}
+
+	buffer.WriteString(fmt.Sprintf("BEGIN %s END", c.query.Build()))
+
+	return buffer.String()
+}
@@ -0,0 +1,76 @@
+package influxquerybuilder
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreateContinuousQuery(t *testing.T) {
+	expected := `CREATE CONTINUOUS QUERY "cq_1h" ON "telemetry" BEGIN SELECT MEAN("temperature") INTO rp_1y."temperature_1h" FROM "measurement" GROUP BY time(1h),sensorId END`
+	q := CreateContinuousQuery("cq_1h", "telemetry").
+		Query(
+			New().
+				Select(`MEAN("temperature")`).
+				IntoRP("rp_1y", "temperature_1h").
+				From("measurement").
+				GroupByTime(NewDuration().Hour(1)).
+				GroupByTag("sensorId"),
+		).
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestCreateContinuousQueryResample(t *testing.T) {
+	expected := `CREATE CONTINUOUS QUERY "cq_1h" ON "telemetry" RESAMPLE EVERY 30m FOR 2h BEGIN SELECT MEAN("temperature") INTO "temperature_1h" FROM "measurement" GROUP BY time(1h) END`
+	q := CreateContinuousQuery("cq_1h", "telemetry").
+		ResampleEvery(NewDuration().Minute(30)).
+		ResampleFor(NewDuration().Hour(2)).
+		Query(
+			New().
+				Select(`MEAN("temperature")`).
+				Into("temperature_1h").
+				From("measurement").
+				GroupByTime(NewDuration().Hour(1)),
+		).
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestCreateContinuousQueryValidate(t *testing.T) {
+	cq := CreateContinuousQuery("cq_1h", "telemetry")
+	assert(t, cq.Validate(), ErrMissingQuery)
+
+	cq.Query(New().Select(`MEAN("temperature")`).From("measurement").GroupByTime(NewDuration().Hour(1)))
+	assert(t, cq.Validate(), ErrMissingInto)
+	assert(t, cq.Build(), "")
+
+	cq.Query(New().Select(`MEAN("temperature")`).Into("temperature_1h").From("measurement"))
+	assert(t, cq.Validate(), ErrMissingGroupByTime)
+
+	cq.Query(New().Into("temperature_1h").From("measurement").GroupByTime(NewDuration().Hour(1)))
+	assert(t, errors.Is(cq.Validate(), ErrMissingFields), true)
+	assert(t, cq.Build(), "")
+
+	cq.Query(New().Select(`MEAN("temperature")`).Into("temperature_1h").GroupByTime(NewDuration().Hour(1)))
+	assert(t, errors.Is(cq.Validate(), ErrMissingMeasurement), true)
+
+	cq.Query(New().Select(`MEAN("temperature")`).Into("temperature_1h").From("measurement").GroupByTime(NewDuration().Hour(1)))
+	cq.ResampleEvery(NewDuration().Infinite())
+	assert(t, cq.Validate() != nil, true)
+}
+
+func TestDropContinuousQuery(t *testing.T) {
+	expected := `DROP CONTINUOUS QUERY "cq_1h" ON "telemetry"`
+	q := DropContinuousQuery("cq_1h", "telemetry").Build()
+
+	assert(t, q, expected)
+}
+
+func TestShowContinuousQueries(t *testing.T) {
+	expected := `SHOW CONTINUOUS QUERIES`
+	q := ShowContinuousQueries().Build()
+
+	assert(t, q, expected)
+}
@@ -0,0 +1,171 @@
+package influxquerybuilder
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrUnsupported The query uses a feature the target language cannot express
+var ErrUnsupported = errors.New("influxquerybuilder: unsupported conversion")
+
+func unsupported(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), ErrUnsupported)
+}
+
+// selectItem A parsed select field
+type selectItem struct {
+	function string
+	key      string
+	cast     KeyType
+	alias    string
+}
+
+var simpleCallMatcher = regexp.MustCompile(`^(\w+)\(\s*("[^"]+"|\w+)(::\w+)?\s*\)$`)
+
+// selectItems splits select fields into plain keys and single argument calls
+func (q *Query) selectItems() ([]selectItem, error) {
+	items := make([]selectItem, 0, len(q.fields))
+
+	for _, field := range q.fields {
+		splitByAs := strings.Split(field, "AS")
+		item := selectItem{}
+		selectField := strings.TrimSpace(splitByAs[0])
+
+		if len(splitByAs) == 2 {
+			item.alias = strings.TrimSpace(splitByAs[1])
+		}
+
+		if match := simpleCallMatcher.FindStringSubmatch(selectField); match != nil {
+			item.function = strings.ToUpper(match[1])
+			selectField = strings.Trim(match[2], `"`) + match[3]
+		} else if functionMatcher.MatchString(selectField) {
+			return nil, unsupported("select %s", selectField)
+		}
+
+		item.key, item.cast = splitCast(selectField)
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// renderCriteria renders the criteria with the structure of buildWhere,
+// using render for each comparison and the given AND/OR keywords
+func (q *Query) renderCriteria(render func(Tag) (string, error), and, or string) (string, error) {
+	var parts []string
+
+	add := func(keyword string, tag Tag) error {
+		s, err := render(tag)
+		if err != nil {
+			return err
+		}
+
+		if keyword != "" {
+			parts = append(parts, keyword)
+		}
+		parts = append(parts, s)
+
+		return nil
+	}
+
+	addBrackets := func(keyword string, b QueryBuilder) error {
+		bq, ok := b.(*Query)
+		if !ok {
+			return unsupported("brackets of type %T", b)
+		}
+
+		s, err := bq.renderCriteria(render, and, or)
+		if err != nil {
+			return err
+		}
+
+		if keyword != "" {
+			parts = append(parts, keyword)
+		}
+		parts = append(parts, "("+s+")")
+
+		return nil
+	}
+
+	if q.where != (Tag{}) {
+		if err := add("", q.where); err != nil {
+			return "", err
+		}
+	} else if q.whereBrackets != nil {
+		if err := addBrackets("", q.whereBrackets); err != nil {
+			return "", err
+		}
+	} else {
+		return "", nil
+	}
+
+	for _, tag := range q.and {
+		if err := add(and, tag); err != nil {
+			return "", err
+		}
+	}
+
+	for _, tag := range q.or {
+		if err := add(or, tag); err != nil {
+			return "", err
+		}
+	}
+
+	for _, b := range q.andBrackets {
+		if err := addBrackets(and, b); err != nil {
+			return "", err
+		}
+	}
+
+	for _, b := range q.orBrackets {
+		if err := addBrackets(or, b); err != nil {
+			return "", err
+		}
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// withoutTimeCriteria splits top level AND time criteria from the others
+func (q *Query) withoutTimeCriteria() (*Query, []Tag, error) {
+	c := *q
+	var times []Tag
+
+	isTime := func(tag Tag) bool {
+		name, _ := splitCast(tag.key)
+		return name == "time"
+	}
+
+	if q.or == nil && q.orBrackets == nil {
+		if isTime(q.where) {
+			times = append(times, q.where)
+			c.where = Tag{}
+			c.whereBrackets = nil
+		}
+
+		c.and = nil
+		for _, tag := range q.and {
+			if isTime(tag) {
+				times = append(times, tag)
+			} else {
+				c.and = append(c.and, tag)
+			}
+		}
+
+		if c.where == (Tag{}) && c.whereBrackets == nil {
+			if len(c.and) > 0 {
+				c.where, c.and = c.and[0], c.and[1:]
+			} else if len(c.andBrackets) > 0 {
+				c.whereBrackets, c.andBrackets = c.andBrackets[0], c.andBrackets[1:]
+			}
+		}
+	}
+
+	if hasTimeCriteria(c.GetQueryStruct()) {
+		return nil, nil, unsupported("time criteria outside of top level AND")
+	}
+
+	return &c, times, nil
+}
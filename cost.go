@@ -0,0 +1,401 @@
+package influxquerybuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultPointInterval Interval between two points of a series when the
+// cardinality does not tell
+const DefaultPointInterval = 10 * time.Second
+
+var (
+	// ErrUnknownCardinality The series count of a measurement is unknown
+	ErrUnknownCardinality = errors.New("influxquerybuilder: unknown cardinality")
+	// ErrTooExpensive A query exceeds a CostLimit
+	ErrTooExpensive = errors.New("influxquerybuilder: query too expensive")
+)
+
+// Cardinality Series cardinality of a database, loaded from SHOW ... CARDINALITY
+// or supplied as a table
+type Cardinality struct {
+	// Series Series count of each measurement
+	Series map[string]int `json:"series" yaml:"series"`
+	// TagValues Distinct values of each tag key of each measurement
+	TagValues map[string]map[string]int `json:"tagValues,omitempty" yaml:"tagValues,omitempty"`
+	// TotalSeries Series count of the database, spread over Measurements for
+	// measurements without a series count
+	TotalSeries  int `json:"totalSeries,omitempty" yaml:"totalSeries,omitempty"`
+	Measurements int `json:"measurements,omitempty" yaml:"measurements,omitempty"`
+	// PointInterval Interval between two points of a series, DefaultPointInterval when 0
+	PointInterval time.Duration `json:"pointInterval,omitempty" yaml:"pointInterval,omitempty"`
+}
+
+// Cost Estimated cost of a query
+type Cost struct {
+	// Series Series read
+	Series int
+	// Points Points read, math.MaxInt64 when Unbounded
+	Points int64
+	// Rows Rows returned, math.MaxInt64 when Unbounded unless aggregated
+	// without GROUP BY time or capped by LIMIT
+	Rows int64
+	// Unbounded The query has no lower time bound and reads every point
+	Unbounded bool
+}
+
+// CostLimit Limits of Cost.Check, zero fields are unlimited
+type CostLimit struct {
+	Series         int
+	Points         int64
+	Rows           int64
+	AllowUnbounded bool
+}
+
+// NewCardinality New Cardinality
+func NewCardinality() *Cardinality {
+	return &Cardinality{Series: map[string]int{}, TagValues: map[string]map[string]int{}}
+}
+
+// LoadCardinality Load a JSON cardinality table
+func LoadCardinality(r io.Reader) (*Cardinality, error) {
+	c := NewCardinality()
+
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// ShowSeriesCardinality SHOW SERIES EXACT CARDINALITY, counting the series of
+// each measurement
+func ShowSeriesCardinality() Statement {
+	return &showStatement{what: "SERIES EXACT CARDINALITY"}
+}
+
+// ShowMeasurementCardinality SHOW MEASUREMENT CARDINALITY
+func ShowMeasurementCardinality() Statement {
+	return &showStatement{what: "MEASUREMENT CARDINALITY"}
+}
+
+// ShowTagValuesCardinality SHOW TAG VALUES EXACT CARDINALITY WITH KEY = "key"
+func ShowTagValuesCardinality(key string) Statement {
+	return &showStatement{what: "TAG VALUES EXACT CARDINALITY WITH KEY =", name: key, named: true}
+}
+
+// LoadSeries Load the output of SHOW SERIES [EXACT] CARDINALITY. Counts
+// without a measurement name add to TotalSeries
+func (c *Cardinality) LoadSeries(resp *Response) error {
+	return c.load(resp, func(name string, n int) {
+		if name == "" {
+			c.TotalSeries += n
+			return
+		}
+
+		if c.Series == nil {
+			c.Series = map[string]int{}
+		}
+		c.Series[name] = n
+	})
+}
+
+// LoadMeasurements Load the output of SHOW MEASUREMENT [EXACT] CARDINALITY
+func (c *Cardinality) LoadMeasurements(resp *Response) error {
+	return c.load(resp, func(name string, n int) {
+		c.Measurements = n
+	})
+}
+
+// LoadTagValues Load the output of SHOW TAG VALUES [EXACT] CARDINALITY WITH KEY = "key"
+func (c *Cardinality) LoadTagValues(key string, resp *Response) error {
+	return c.load(resp, func(name string, n int) {
+		if c.TagValues == nil {
+			c.TagValues = map[string]map[string]int{}
+		}
+
+		if c.TagValues[name] == nil {
+			c.TagValues[name] = map[string]int{}
+		}
+		c.TagValues[name][key] = n
+	})
+}
+
+func (c *Cardinality) load(resp *Response, add func(string, int)) error {
+	if err := resp.Error(); err != nil {
+		return err
+	}
+
+	for _, r := range resp.Results {
+		if err := r.Error(); err != nil {
+			return err
+		}
+
+		for _, series := range r.Series {
+			for _, values := range series.Values {
+				if len(values) == 0 {
+					continue
+				}
+
+				n, ok := number(values[len(values)-1])
+				if !ok {
+					return fmt.Errorf("influxquerybuilder: cardinality %v of %q is not a number", values, series.Name)
+				}
+
+				add(series.Name, int(n))
+			}
+		}
+	}
+
+	return nil
+}
+
+// Estimate Estimate the series and points a query reads and the rows it
+// returns. Tag criteria are assumed independent, regexes may match every
+// value, and open ended time ranges end at now
+func (c *Cardinality) Estimate(builder QueryBuilder, now time.Time) (Cost, error) {
+	q, ok := builder.(*Query)
+	if !ok {
+		q = &Query{}
+		if _, err := q.FromSpec(builder.ToSpec()); err != nil {
+			return Cost{}, err
+		}
+	}
+
+	series, err := c.seriesOf(q.measurement)
+	if err != nil {
+		return Cost{}, err
+	}
+
+	cost := Cost{Series: int(math.Ceil(float64(series) * c.selectivity(q.measurement, q.GetQueryStruct())))}
+
+	start, end, bounded := costTimeRange(q, now)
+	if !bounded {
+		cost.Unbounded = true
+	}
+
+	interval := c.PointInterval
+	if interval <= 0 {
+		interval = DefaultPointInterval
+	}
+
+	span := end.Sub(start)
+	if span < 0 {
+		span = 0
+	}
+
+	if bounded {
+		cost.Points = int64(cost.Series) * max(int64(math.Ceil(float64(span)/float64(interval))), 1)
+	} else {
+		cost.Points = math.MaxInt64
+	}
+
+	outSeries := c.outputSeries(q, cost.Series)
+
+	aggregate := false
+	for _, f := range q.fields {
+		if functionMatcher.MatchString(strings.TrimSpace(strings.Split(f, " AS ")[0])) {
+			aggregate = true
+		}
+	}
+
+	switch {
+	case aggregate && q.groupByTime != "" && !bounded:
+		cost.Rows = math.MaxInt64
+	case aggregate && q.groupByTime != "":
+		buckets := int64(1)
+		if d, ok := groupByDuration(q.groupByTime); ok && d > 0 {
+			buckets = int64(math.Ceil(float64(span) / float64(d)))
+		}
+		cost.Rows = int64(outSeries) * buckets
+	case aggregate:
+		cost.Rows = int64(outSeries)
+	default:
+		cost.Rows = cost.Points
+	}
+
+	if q._limit && cost.Rows > int64(outSeries)*int64(q.limit) {
+		cost.Rows = int64(outSeries) * int64(q.limit)
+	}
+
+	return cost, nil
+}
+
+// Check ErrTooExpensive when the cost exceeds a limit
+func (c Cost) Check(limit CostLimit) error {
+	switch {
+	case c.Unbounded && !limit.AllowUnbounded:
+		return fmt.Errorf("no lower time bound: %w", ErrTooExpensive)
+	case c.Unbounded && limit.Points > 0:
+		return fmt.Errorf("no lower time bound, more than %d points: %w", limit.Points, ErrTooExpensive)
+	case limit.Series > 0 && c.Series > limit.Series:
+		return fmt.Errorf("%d series, more than %d: %w", c.Series, limit.Series, ErrTooExpensive)
+	case limit.Points > 0 && c.Points > limit.Points:
+		return fmt.Errorf("%d points, more than %d: %w", c.Points, limit.Points, ErrTooExpensive)
+	case limit.Rows > 0 && c.Rows > limit.Rows:
+		return fmt.Errorf("%d rows, more than %d: %w", c.Rows, limit.Rows, ErrTooExpensive)
+	}
+
+	return nil
+}
+
+// seriesOf series count of a measurement, the average of the database when
+// it has no count of its own
+func (c *Cardinality) seriesOf(measurement string) (int, error) {
+	if n, ok := c.Series[measurement]; ok {
+		return n, nil
+	}
+
+	if c.TotalSeries > 0 && c.Measurements > 0 {
+		return (c.TotalSeries + c.Measurements - 1) / c.Measurements, nil
+	}
+
+	return 0, fmt.Errorf("measurement %q: %w", measurement, ErrUnknownCardinality)
+}
+
+// tagValues distinct values of a tag, 0 for fields and unknown tags
+func (c *Cardinality) tagValues(measurement, key string) int {
+	name, _ := splitCast(key)
+	return c.TagValues[measurement][name]
+}
+
+// selectivity share of the series matched by the criteria, AND before OR
+func (c *Cardinality) selectivity(measurement string, q CurrentQuery) float64 {
+	var groups []float64
+
+	add := func(or bool, s float64) {
+		if or || groups == nil {
+			groups = append(groups, s)
+		} else {
+			groups[len(groups)-1] *= s
+		}
+	}
+
+	if q.WhereBrackets != nil && q.Where == (Tag{}) {
+		add(false, c.selectivity(measurement, q.WhereBrackets.GetQueryStruct()))
+	} else if q.Where != (Tag{}) {
+		add(false, c.tagSelectivity(measurement, q.Where))
+	}
+
+	for _, tag := range q.And {
+		add(false, c.tagSelectivity(measurement, tag))
+	}
+
+	for _, tag := range q.Or {
+		add(true, c.tagSelectivity(measurement, tag))
+	}
+
+	for _, b := range q.AndBrackets {
+		add(false, c.selectivity(measurement, b.GetQueryStruct()))
+	}
+
+	for _, b := range q.OrBrackets {
+		add(true, c.selectivity(measurement, b.GetQueryStruct()))
+	}
+
+	if groups == nil {
+		return 1
+	}
+
+	sum := 0.0
+	for _, s := range groups {
+		sum += s
+	}
+
+	return math.Min(sum, 1)
+}
+
+// tagSelectivity share of the series matched by a condition. Only = and !=
+// on tags of known cardinality select series
+func (c *Cardinality) tagSelectivity(measurement string, tag Tag) float64 {
+	n := c.tagValues(measurement, tag.key)
+	if n <= 0 {
+		return 1
+	}
+
+	switch tag.value.(type) {
+	case Variable, *regexp.Regexp:
+		return 1
+	}
+
+	switch Operator(tag.op) {
+	case Eq:
+		return 1 / float64(n)
+	case Neq, "<>":
+		return 1 - 1/float64(n)
+	}
+
+	return 1
+}
+
+// outputSeries series of the result, from the GROUP BY tags
+func (c *Cardinality) outputSeries(q *Query, read int) int {
+	if len(q.groupByTags) == 0 {
+		return 1
+	}
+
+	n := 1
+	for _, tag := range q.groupByTags {
+		values := c.tagValues(q.measurement, tag)
+		if tag == "*" || values <= 0 {
+			return read
+		}
+
+		n *= values
+		if n >= read {
+			return read
+		}
+	}
+
+	return n
+}
+
+// costTimeRange time range of the query, open ends end at now and a missing
+// lower bound makes it unbounded
+func costTimeRange(q *Query, now time.Time) (time.Time, time.Time, bool) {
+	end := now
+	var start time.Time
+
+	_, times, err := q.withoutTimeCriteria()
+	if err != nil {
+		return start, end, false
+	}
+
+	for _, tag := range times {
+		t, ok := criteriaTime(tag.value)
+		if !ok {
+			continue
+		}
+
+		switch Operator(tag.op) {
+		case Gt, Gte:
+			if start.IsZero() || t.After(start) {
+				start = t
+			}
+		case Lt, Lte:
+			if t.Before(end) {
+				end = t
+			}
+		case Eq:
+			start, end = t, t
+		}
+	}
+
+	return start, end, !start.IsZero()
+}
+
+// groupByDuration interval of GROUP BY time(...)
+func groupByDuration(groupByTime string) (time.Duration, bool) {
+	d, err := ParseDuration(strings.TrimSuffix(strings.TrimPrefix(groupByTime, "time("), ")"))
+	if err != nil {
+		return 0, false
+	}
+
+	return toTimeDuration(d)
+}
@@ -0,0 +1,153 @@
+package influxquerybuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const cardinalityJSON = `{
+  "series": {"cpu": 1000},
+  "tagValues": {"cpu": {"host": 100, "region": 5, "cpu": 2}},
+  "pointInterval": 60000000000
+}`
+
+var costNow = time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+
+func loadTestCardinality(t *testing.T) *Cardinality {
+	c, err := LoadCardinality(strings.NewReader(cardinalityJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestEstimate(t *testing.T) {
+	c := loadTestCardinality(t)
+	day := func() QueryBuilder {
+		return New().From("cpu").Where("time", ">=", "2020-01-01T00:00:00Z").And("time", "<", "2020-01-02T00:00:00Z")
+	}
+
+	for _, tc := range []struct {
+		builder  QueryBuilder
+		expected Cost
+	}{
+		{day().Select("usage"), Cost{Series: 1000, Points: 1440000, Rows: 1440000}},
+		{day().Select("usage").AndEq("host", "a"), Cost{Series: 10, Points: 14400, Rows: 14400}},
+		{day().Select("usage").AndEq("host", "a").AndEq("region", "eu"), Cost{Series: 2, Points: 2880, Rows: 2880}},
+		{day().Select("usage").AndIn("host", "a", "b"), Cost{Series: 20, Points: 28800, Rows: 28800}},
+		{day().Select("usage").And("region", "!=", "eu"), Cost{Series: 800, Points: 1152000, Rows: 1152000}},
+		{day().Select("usage").And("host", "=~", regexp.MustCompile("^web")), Cost{Series: 1000, Points: 1440000, Rows: 1440000}},
+		{day().Select("usage").And("usage", ">", 90), Cost{Series: 1000, Points: 1440000, Rows: 1440000}},
+		{day().Select("usage").AndEq("host", "a").Limit(100), Cost{Series: 10, Points: 14400, Rows: 100}},
+		{day().Select(`MEAN("usage")`).GroupByTime(NewDuration().Hour(1)), Cost{Series: 1000, Points: 1440000, Rows: 24}},
+		{day().Select(`MEAN("usage")`).GroupByTime(NewDuration().Hour(1)).GroupByTag("region"), Cost{Series: 1000, Points: 1440000, Rows: 120}},
+		{day().Select(`MEAN("usage") AS m`).GroupByTag("host", "cpu"), Cost{Series: 1000, Points: 1440000, Rows: 200}},
+		{day().Select(`MEAN("usage")`).GroupByTag("*"), Cost{Series: 1000, Points: 1440000, Rows: 1000}},
+		{New().Select("usage").From("cpu").Where("time", ">", "2020-01-01T23:00:00Z").AndEq("host", "a"), Cost{Series: 10, Points: 600, Rows: 600}},
+		{New().Select("usage").From("cpu").Where("time", "=", "2020-01-01T23:00:00Z"), Cost{Series: 1000, Points: 1000, Rows: 1000}},
+		{New().Select("usage").From("cpu").WhereEq("host", "a"), Cost{Series: 10, Points: math.MaxInt64, Rows: math.MaxInt64, Unbounded: true}},
+		{New().Select("usage").From("cpu").WhereEq("host", "a").Limit(5), Cost{Series: 10, Points: math.MaxInt64, Rows: 5, Unbounded: true}},
+		{New().Select(`MEAN("usage")`).From("cpu").GroupByTime(NewDuration().Minute(1)), Cost{Series: 1000, Points: math.MaxInt64, Rows: math.MaxInt64, Unbounded: true}},
+		{New().Select(`MEAN("usage")`).From("cpu"), Cost{Series: 1000, Points: math.MaxInt64, Rows: 1, Unbounded: true}},
+	} {
+		cost, err := c.Estimate(tc.builder, costNow)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cost != tc.expected {
+			t.Errorf("Expected %+v but got %+v for %s", tc.expected, cost, tc.builder.Build())
+		}
+	}
+}
+
+func TestEstimateUnknown(t *testing.T) {
+	c := loadTestCardinality(t)
+
+	_, err := c.Estimate(New().Select("used").From("mem"), costNow)
+	if !errors.Is(err, ErrUnknownCardinality) {
+		t.Errorf("Expected ErrUnknownCardinality but got %v", err)
+	}
+
+	c.TotalSeries, c.Measurements = 3001, 3
+
+	cost, err := c.Estimate(New().Select("used").From("mem").Where("time", ">", "2020-01-01T23:59:00Z"), costNow)
+	assert(t, err, nil)
+	assert(t, cost, Cost{Series: 1001, Points: 1001, Rows: 1001})
+}
+
+func TestCostCheck(t *testing.T) {
+	cost := Cost{Series: 10, Points: 1000, Rows: 100}
+
+	assert(t, cost.Check(CostLimit{}), nil)
+	assert(t, cost.Check(CostLimit{Series: 10, Points: 1000, Rows: 100}), nil)
+
+	for _, limit := range []CostLimit{{Series: 9}, {Points: 999}, {Rows: 99}} {
+		if err := cost.Check(limit); !errors.Is(err, ErrTooExpensive) {
+			t.Errorf("Expected ErrTooExpensive for %+v but got %v", limit, err)
+		}
+	}
+
+	unbounded := Cost{Series: 1, Unbounded: true}
+	assert(t, errors.Is(unbounded.Check(CostLimit{}), ErrTooExpensive), true)
+	assert(t, unbounded.Check(CostLimit{AllowUnbounded: true}), nil)
+	assert(t, errors.Is(unbounded.Check(CostLimit{AllowUnbounded: true, Points: 1000}), ErrTooExpensive), true)
+
+	c := loadTestCardinality(t)
+	estimated, err := c.Estimate(New().Select("usage").From("cpu"), costNow)
+	assert(t, err, nil)
+	assert(t, errors.Is(estimated.Check(CostLimit{AllowUnbounded: true, Rows: 1000}), ErrTooExpensive), true)
+}
+
+func TestLoadCardinality(t *testing.T) {
+	c := NewCardinality()
+
+	series := &Response{Results: []Result{{Series: []Series{
+		{Name: "cpu", Columns: []string{"count"}, Values: [][]interface{}{{json.Number("1000")}}},
+		{Name: "mem", Columns: []string{"count"}, Values: [][]interface{}{{json.Number("20")}}},
+	}}}}
+	estimate := &Response{Results: []Result{{Series: []Series{
+		{Columns: []string{"cardinality estimation"}, Values: [][]interface{}{{json.Number("1100")}}},
+	}}}}
+	measurements := &Response{Results: []Result{{Series: []Series{
+		{Columns: []string{"cardinality estimation"}, Values: [][]interface{}{{float64(4)}}},
+	}}}}
+	hosts := &Response{Results: []Result{{Series: []Series{
+		{Name: "cpu", Columns: []string{"count"}, Values: [][]interface{}{{json.Number("100")}}},
+		{Name: "mem", Columns: []string{"count"}, Values: [][]interface{}{{json.Number("20")}}},
+	}}}}
+
+	assert(t, c.LoadSeries(series), nil)
+	assert(t, c.LoadSeries(estimate), nil)
+	assert(t, c.LoadMeasurements(measurements), nil)
+	assert(t, c.LoadMeasurements(measurements), nil)
+	assert(t, c.LoadTagValues("host", hosts), nil)
+
+	assert(t, c.Series["cpu"], 1000)
+	assert(t, c.Series["mem"], 20)
+	assert(t, c.TotalSeries, 1100)
+	assert(t, c.Measurements, 4)
+	assert(t, c.TagValues["cpu"]["host"], 100)
+	assert(t, c.TagValues["mem"]["host"], 20)
+
+	err := c.LoadSeries(&Response{Results: []Result{{Err: "database not found: x"}}})
+	assert(t, err.Error(), "database not found: x")
+
+	err = c.LoadSeries(&Response{Results: []Result{{Series: []Series{{Name: "cpu", Values: [][]interface{}{{"many"}}}}}}})
+	if err == nil {
+		t.Error("Expected an error for a non numeric count")
+	}
+}
+
+func TestShowCardinality(t *testing.T) {
+	assert(t, ShowSeriesCardinality().Build(), "SHOW SERIES EXACT CARDINALITY")
+	assert(t, ShowMeasurementCardinality().Build(), "SHOW MEASUREMENT CARDINALITY")
+	assert(t, ShowTagValuesCardinality("host").Build(), `SHOW TAG VALUES EXACT CARDINALITY WITH KEY = "host"`)
+	assert(t, ShowTagValuesCardinality("").Build(), "")
+}
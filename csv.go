@@ -0,0 +1,158 @@
+package influxquerybuilder
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// decodeCSV decodes an application/csv response. The format has no
+// statement_id, so results are numbered in order and results without series
+// are lost. Times are epoch nanoseconds unless the request sets epoch
+func decodeCSV(r io.Reader) (*Response, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	resp := &Response{}
+	var columns []string
+	var series *Series
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(record) == 1 && record[0] == "error" && columns == nil {
+			message, err := reader.Read()
+			if err != nil || len(message) == 0 {
+				return nil, errors.New("influxquerybuilder: csv error without message")
+			}
+
+			resp.Err = message[0]
+			return resp, nil
+		}
+
+		// Each result starts with a header, after a blank line
+		if len(record) >= 2 && record[0] == "name" && record[1] == "tags" {
+			columns = record[2:]
+			series = nil
+			resp.Results = append(resp.Results, Result{StatementID: len(resp.Results)})
+			continue
+		}
+
+		if columns == nil || len(record) != len(columns)+2 {
+			return nil, errors.New("influxquerybuilder: csv row does not match its header")
+		}
+
+		result := &resp.Results[len(resp.Results)-1]
+		tags, err := parseTags(record[1])
+		if err != nil {
+			return nil, err
+		}
+
+		if series == nil || series.Name != record[0] || !sameTags(series.Tags, tags) {
+			result.Series = append(result.Series, Series{Name: record[0], Tags: tags, Columns: columns})
+			series = &result.Series[len(result.Series)-1]
+		}
+
+		values := make([]interface{}, len(columns))
+		for i, v := range record[2:] {
+			values[i] = csvValue(v)
+		}
+
+		series.Values = append(series.Values, values)
+	}
+
+	return resp, nil
+}
+
+// csvValue types a csv value the way it would be decoded from JSON
+func csvValue(v string) interface{} {
+	switch v {
+	case "":
+		return nil
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return json.Number(v)
+	}
+
+	return v
+}
+
+// parseTags parses a series key of tags, e.g. host=a,region=eu, with
+// backslash escaped commas, equal signs and spaces
+func parseTags(key string) (map[string]string, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	tags := map[string]string{}
+	var k, part strings.Builder
+	inValue := false
+
+	flush := func() error {
+		if !inValue {
+			return errors.New("influxquerybuilder: invalid tags " + key)
+		}
+
+		tags[k.String()] = part.String()
+		k.Reset()
+		part.Reset()
+		inValue = false
+
+		return nil
+	}
+
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+
+		switch {
+		case c == '\\' && i+1 < len(key):
+			i++
+			part.WriteByte(key[i])
+		case c == '=' && !inValue:
+			k.WriteString(part.String())
+			part.Reset()
+			inValue = true
+		case c == ',':
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		default:
+			part.WriteByte(c)
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func sameTags(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+
+	return true
+}
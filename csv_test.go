@@ -0,0 +1,41 @@
+package influxquerybuilder
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tags, err := parseTags(`host=a\,b,path=/var\ log,k\=v=x`)
+	assert(t, err, nil)
+	assert(t, reflect.DeepEqual(tags, map[string]string{"host": "a,b", "path": "/var log", "k=v": "x"}), true)
+
+	tags, err = parseTags("")
+	assert(t, err, nil)
+	assert(t, tags == nil, true)
+
+	_, err = parseTags("host")
+	assert(t, err.Error(), "influxquerybuilder: invalid tags host")
+}
+
+func TestCSVValue(t *testing.T) {
+	assert(t, csvValue(""), nil)
+	assert(t, csvValue("true"), true)
+	assert(t, csvValue("12"), json.Number("12"))
+	assert(t, csvValue("-1.5e3"), json.Number("-1.5e3"))
+	assert(t, csvValue("ok"), "ok")
+}
+
+func TestDecodeCSVErrors(t *testing.T) {
+	_, err := decodeCSV(strings.NewReader("cpu,,1,2\n"))
+	assert(t, err.Error(), "influxquerybuilder: csv row does not match its header")
+
+	_, err = decodeCSV(strings.NewReader("name,tags,time\ncpu,,1,2\n"))
+	assert(t, err.Error(), "influxquerybuilder: csv row does not match its header")
+
+	resp, err := decodeCSV(strings.NewReader(""))
+	assert(t, err, nil)
+	assert(t, len(resp.Results), 0)
+}
@@ -0,0 +1,152 @@
+package influxquerybuilder
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// DatabaseBuilder DatabaseBuilder interface
+type DatabaseBuilder interface {
+	Duration(Duration) DatabaseBuilder
+	Replication(uint) DatabaseBuilder
+	ShardDuration(Duration) DatabaseBuilder
+	Name(string) DatabaseBuilder
+	Statement
+}
+
+// Database Database struct
+type Database struct {
+	name          string
+	duration      Duration
+	replication   uint
+	_replication  bool
+	shardDuration Duration
+	policyName    string
+}
+
+// CreateDatabase CREATE DATABASE "name"
+func CreateDatabase(name string) DatabaseBuilder {
+	return &Database{name: name}
+}
+
+// Duration WITH DURATION x, INF is allowed
+func (d *Database) Duration(duration Duration) DatabaseBuilder {
+	d.duration = duration
+	return d
+}
+
+// Replication WITH REPLICATION x
+func (d *Database) Replication(n uint) DatabaseBuilder {
+	d._replication = true
+	d.replication = n
+	return d
+}
+
+// ShardDuration WITH SHARD DURATION x
+func (d *Database) ShardDuration(duration Duration) DatabaseBuilder {
+	d.shardDuration = duration
+	return d
+}
+
+// Name WITH NAME "rp", the name of the default retention policy
+func (d *Database) Name(name string) DatabaseBuilder {
+	d.policyName = name
+	return d
+}
+
+// Validate Validate the statement
+func (d *Database) Validate() error {
+	if err := validateName("database", d.name); err != nil {
+		return err
+	}
+
+	if d.duration != nil {
+		if err := validateDuration("duration", d.duration, true); err != nil {
+			return err
+		}
+	}
+
+	if d._replication && d.replication < 1 {
+		return ErrInvalidReplication
+	}
+
+	if d.shardDuration != nil {
+		if err := validateDuration("shard duration", d.shardDuration, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Build Build statement string, empty if the statement is invalid
+func (d *Database) Build() string {
+	if d.Validate() != nil {
+		return ""
+	}
+
+	var buffer bytes.Buffer
+
+	buffer.WriteString(fmt.Sprintf("CREATE DATABASE %s", quoteIdent(d.name)))
+
+	if d.duration == nil && !d._replication && d.shardDuration == nil && d.policyName == "" {
+		return buffer.String()
+	}
+
+	buffer.WriteString(" WITH")
+
+	if d.duration != nil {
+		buffer.WriteString(fmt.Sprintf(" DURATION %s", d.duration.getLiteral()))
+	}
+
+	if d._replication {
+		buffer.WriteString(fmt.Sprintf(" REPLICATION %d", d.replication))
+	}
+
+	if d.shardDuration != nil {
+		buffer.WriteString(fmt.Sprintf(" SHARD DURATION %s", d.shardDuration.getLiteral()))
+	}
+
+	if d.policyName != "" {
+		buffer.WriteString(fmt.Sprintf(" NAME %s", quoteIdent(d.policyName)))
+	}
+
+	return buffer.String()
+}
+
+// dropStatement DROP <kind> "name" [ON "db"]
+type dropStatement struct {
+	kind     string
+	name     string
+	database string
+	on       bool
+}
+
+// DropDatabase DROP DATABASE "name"
+func DropDatabase(name string) Statement {
+	return &dropStatement{kind: "DATABASE", name: name}
+}
+
+func (s *dropStatement) Validate() error {
+	if err := validateName(s.kind, s.name); err != nil {
+		return err
+	}
+
+	if s.on {
+		return validateName("database", s.database)
+	}
+
+	return nil
+}
+
+func (s *dropStatement) Build() string {
+	if s.Validate() != nil {
+		return ""
+	}
+
+	if s.on {
+		return fmt.Sprintf("DROP %s %s ON %s", s.kind, quoteIdent(s.name), quoteIdent(s.database))
+	}
+
+	return fmt.Sprintf("DROP %s %s", s.kind, quoteIdent(s.name))
+}
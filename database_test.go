@@ -0,0 +1,65 @@
+package influxquerybuilder
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreateDatabase(t *testing.T) {
+	expected := `CREATE DATABASE "telemetry"`
+	q := CreateDatabase("telemetry").Build()
+
+	assert(t, q, expected)
+}
+
+func TestCreateDatabaseWith(t *testing.T) {
+	expected := `CREATE DATABASE "telemetry" WITH DURATION 30d REPLICATION 1 SHARD DURATION 1d NAME "thirty_days"`
+	q := CreateDatabase("telemetry").
+		Duration(NewDuration().Day(30)).
+		Replication(1).
+		ShardDuration(NewDuration().Day(1)).
+		Name("thirty_days").
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestCreateDatabaseInfinite(t *testing.T) {
+	expected := `CREATE DATABASE "telemetry" WITH DURATION INF`
+	q := CreateDatabase("telemetry").
+		Duration(NewDuration().Infinite()).
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestCreateDatabaseValidate(t *testing.T) {
+	err := CreateDatabase("").Validate()
+	assert(t, errors.Is(err, ErrEmptyName), true)
+	assert(t, CreateDatabase("").Build(), "")
+
+	err = CreateDatabase("telemetry").Replication(0).Validate()
+	assert(t, errors.Is(err, ErrInvalidReplication), true)
+
+	err = CreateDatabase("telemetry").ShardDuration(NewDuration().Infinite()).Validate()
+	assert(t, errors.Is(err, ErrInvalidDuration), true)
+
+	err = CreateDatabase("telemetry").Duration(NewDuration()).Validate()
+	assert(t, errors.Is(err, ErrInvalidDuration), true)
+}
+
+func TestDropDatabase(t *testing.T) {
+	expected := `DROP DATABASE "telemetry"`
+	q := DropDatabase("telemetry").Build()
+
+	assert(t, q, expected)
+	assert(t, errors.Is(DropDatabase(" ").Validate(), ErrEmptyName), true)
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	expected := `CREATE DATABASE "my \"db\""`
+	q := CreateDatabase(`my "db"`).Build()
+
+	assert(t, q, expected)
+	assert(t, CreateDatabase(`db\`).Build(), `CREATE DATABASE "db\\"`)
+}
@@ -0,0 +1,164 @@
+package influxquerybuilder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnboundedDelete A DELETE or DROP SERIES without WHERE criteria
+	ErrUnboundedDelete = errors.New("influxquerybuilder: unbounded delete, call AllowUnbounded to permit it")
+	// ErrEmptyDelete A DELETE or DROP SERIES without both FROM and WHERE
+	ErrEmptyDelete = errors.New("influxquerybuilder: delete needs FROM or WHERE")
+	// ErrMissingWhere AND or OR criteria without the WHERE criteria they
+	// follow, which are not rendered
+	ErrMissingWhere = errors.New("influxquerybuilder: AND or OR criteria without WHERE")
+	// ErrTimeCriteria DROP SERIES does not support time criteria
+	ErrTimeCriteria = errors.New("influxquerybuilder: drop series does not support time criteria")
+)
+
+// DeleteBuilder DeleteBuilder interface, shared by DELETE and DROP SERIES
+type DeleteBuilder interface {
+	From(string) DeleteBuilder
+	Where(string, string, interface{}) DeleteBuilder
+	And(string, string, interface{}) DeleteBuilder
+	Or(string, string, interface{}) DeleteBuilder
+	WhereBrackets(QueryBuilder) DeleteBuilder
+	AndBrackets(QueryBuilder) DeleteBuilder
+	OrBrackets(QueryBuilder) DeleteBuilder
+	AllowUnbounded() DeleteBuilder
+	Statement
+}
+
+// DeleteQuery DeleteQuery struct
+type DeleteQuery struct {
+	verb      string
+	noTime    bool
+	unbounded bool
+	query     *Query
+}
+
+// Delete DELETE FROM "measurement" WHERE ...
+func Delete() DeleteBuilder {
+	return &DeleteQuery{verb: "DELETE", query: &Query{}}
+}
+
+// DropSeries DROP SERIES FROM "measurement" WHERE ...
+func DropSeries() DeleteBuilder {
+	return &DeleteQuery{verb: "DROP SERIES", noTime: true, query: &Query{}}
+}
+
+// From From measurement
+func (d *DeleteQuery) From(measurement string) DeleteBuilder {
+	d.query.From(measurement)
+	return d
+}
+
+// Where Where criteria
+func (d *DeleteQuery) Where(key string, op string, value interface{}) DeleteBuilder {
+	d.query.Where(key, op, value)
+	return d
+}
+
+// And And criteria
+func (d *DeleteQuery) And(key string, op string, value interface{}) DeleteBuilder {
+	d.query.And(key, op, value)
+	return d
+}
+
+// Or Or criteria
+func (d *DeleteQuery) Or(key string, op string, value interface{}) DeleteBuilder {
+	d.query.Or(key, op, value)
+	return d
+}
+
+// WhereBrackets WHERE (...)
+func (d *DeleteQuery) WhereBrackets(builder QueryBuilder) DeleteBuilder {
+	d.query.WhereBrackets(builder)
+	return d
+}
+
+// AndBrackets AND (...)
+func (d *DeleteQuery) AndBrackets(builder QueryBuilder) DeleteBuilder {
+	d.query.AndBrackets(builder)
+	return d
+}
+
+// OrBrackets OR (...)
+func (d *DeleteQuery) OrBrackets(builder QueryBuilder) DeleteBuilder {
+	d.query.OrBrackets(builder)
+	return d
+}
+
+// AllowUnbounded Permit rendering without WHERE criteria
+func (d *DeleteQuery) AllowUnbounded() DeleteBuilder {
+	d.unbounded = true
+	return d
+}
+
+// Validate Validate the statement
+func (d *DeleteQuery) Validate() error {
+	bounded := d.query.where != (Tag{}) || d.query.whereBrackets != nil
+
+	q := d.query
+	if !bounded && (q.and != nil || q.or != nil || q.andBrackets != nil || q.orBrackets != nil) {
+		return ErrMissingWhere
+	}
+
+	if !bounded && d.query.measurement == "" {
+		return ErrEmptyDelete
+	}
+
+	if !bounded && !d.unbounded {
+		return ErrUnboundedDelete
+	}
+
+	if err := d.query.validateCriteria(); err != nil {
+		return err
+	}
+
+	if d.noTime && hasTimeCriteria(d.query.GetQueryStruct()) {
+		return ErrTimeCriteria
+	}
+
+	return nil
+}
+
+// Build Build statement string, empty if the statement is invalid
+func (d *DeleteQuery) Build() string {
+	if d.Validate() != nil {
+		return ""
+	}
+
+	var buffer bytes.Buffer
+
+	buffer.WriteString(fmt.Sprintf("%s ", d.verb))
+	buffer.WriteString(d.query.buildFrom())
+	buffer.WriteString(d.query.buildWhere())
+
+	return strings.TrimSpace(buffer.String())
+}
+
+func hasTimeCriteria(q CurrentQuery) bool {
+	tags := append([]Tag{q.Where}, q.And...)
+	tags = append(tags, q.Or...)
+
+	for _, tag := range tags {
+		if tag.key == "time" {
+			return true
+		}
+	}
+
+	brackets := append([]QueryBuilder{q.WhereBrackets}, q.AndBrackets...)
+	brackets = append(brackets, q.OrBrackets...)
+
+	for _, b := range brackets {
+		if b != nil && hasTimeCriteria(b.GetQueryStruct()) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,85 @@
+package influxquerybuilder
+
+import (
+	"testing"
+)
+
+func TestDelete(t *testing.T) {
+	expected := `DELETE FROM "measurement" WHERE "time" < '2018-11-01T00:00:00Z'`
+	q := Delete().
+		From("measurement").
+		Where("time", "<", "2018-11-01T00:00:00Z").
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestDeleteCriteria(t *testing.T) {
+	expected := `DELETE FROM "measurement" WHERE "time" < '2018-11-01T00:00:00Z' AND "host" = 'x' AND ("region" = 'eu' OR "region" = 'us')`
+	q := Delete().
+		From("measurement").
+		Where("time", "<", "2018-11-01T00:00:00Z").
+		And("host", "=", "x").
+		AndBrackets(
+			New().
+				Where("region", "=", "eu").
+				Or("region", "=", "us"),
+		).
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestDeleteUnbounded(t *testing.T) {
+	builder := Delete().From("measurement")
+	assert(t, builder.Validate(), ErrUnboundedDelete)
+	assert(t, builder.Build(), "")
+
+	expected := `DELETE FROM "measurement"`
+	q := builder.AllowUnbounded().Build()
+	assert(t, q, expected)
+
+	assert(t, Delete().AllowUnbounded().Validate(), ErrEmptyDelete)
+}
+
+func TestDeleteMissingWhere(t *testing.T) {
+	builder := Delete().From("cpu").And("host", "=", "a").AllowUnbounded()
+	assert(t, builder.Validate(), ErrMissingWhere)
+	assert(t, builder.Build(), "")
+
+	builder = DropSeries().From("cpu").OrBrackets(New().Where("host", "=", "a")).AllowUnbounded()
+	assert(t, builder.Validate(), ErrMissingWhere)
+}
+
+func TestDropSeries(t *testing.T) {
+	expected := `DROP SERIES FROM "measurement" WHERE "host" = 'x'`
+	q := DropSeries().
+		From("measurement").
+		Where("host", "=", "x").
+		Build()
+
+	assert(t, q, expected)
+
+	expected = `DROP SERIES WHERE "host" = 'x'`
+	q = DropSeries().
+		Where("host", "=", "x").
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestDropSeriesTimeCriteria(t *testing.T) {
+	err := DropSeries().
+		From("measurement").
+		Where("host", "=", "x").
+		And("time", "<", "2018-11-01T00:00:00Z").
+		Validate()
+	assert(t, err, ErrTimeCriteria)
+
+	err = DropSeries().
+		From("measurement").
+		Where("host", "=", "x").
+		OrBrackets(New().Where("time", "<", "2018-11-01T00:00:00Z")).
+		Validate()
+	assert(t, err, ErrTimeCriteria)
+}
@@ -0,0 +1,330 @@
+package influxquerybuilder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMissingBucket BuildFlux without a bucket
+var ErrMissingBucket = errors.New("influxquerybuilder: flux needs a bucket")
+
+// fluxAggregates InfluxQL functions with a Flux equivalent
+var fluxAggregates = map[string]string{
+	"MEAN": "mean", "SUM": "sum", "COUNT": "count", "MAX": "max", "MIN": "min",
+	"MEDIAN": "median", "FIRST": "first", "LAST": "last", "SPREAD": "spread", "STDDEV": "stddev",
+}
+
+var fluxDurationUnits = map[string]string{"u": "us"}
+
+// BuildFlux Convert the query to Flux. Criteria keys are compared as tag
+// columns, except keys cast to a field type or named like a selected field,
+// which are only supported on the single selected field. Time criteria must
+// be top level AND criteria.
+func (q *Query) BuildFlux(bucket string) (string, error) {
+	if bucket == "" {
+		return "", ErrMissingBucket
+	}
+
+	if err := q.Validate(); err != nil {
+		return "", err
+	}
+
+	if q.into != "" {
+		return "", unsupported("INTO")
+	}
+
+	items, err := q.selectItems()
+	if err != nil {
+		return "", err
+	}
+
+	criteria, times, err := q.withoutTimeCriteria()
+	if err != nil {
+		return "", err
+	}
+
+	start, stop, err := fluxRange(times)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	var imports []string
+
+	buffer.WriteString(fmt.Sprintf("from(bucket: %s)\n", strconv.Quote(bucket)))
+	buffer.WriteString(fmt.Sprintf("  |> range(start: %s", start))
+	if stop != "" {
+		buffer.WriteString(fmt.Sprintf(", stop: %s", stop))
+	}
+	buffer.WriteString(")\n")
+	buffer.WriteString(fmt.Sprintf("  |> filter(fn: (r) => r._measurement == %s)\n", strconv.Quote(q.measurement)))
+
+	fn, fields, err := fluxFields(items)
+	if err != nil {
+		return "", err
+	}
+
+	if len(fields) > 0 {
+		conditions := make([]string, len(fields))
+		for i, f := range fields {
+			conditions[i] = fmt.Sprintf("r._field == %s", strconv.Quote(f))
+		}
+		buffer.WriteString(fmt.Sprintf("  |> filter(fn: (r) => %s)\n", strings.Join(conditions, " or ")))
+	}
+
+	filter, err := criteria.renderCriteria(fluxComparison(fields), "and", "or")
+	if err != nil {
+		return "", err
+	}
+
+	if filter != "" {
+		buffer.WriteString(fmt.Sprintf("  |> filter(fn: (r) => %s)\n", filter))
+	}
+
+	tags := make([]string, 0, len(q.groupByTags))
+	for _, tag := range q.groupByTags {
+		if tag == "*" {
+			return "", unsupported("GROUP BY *")
+		}
+		tags = append(tags, strconv.Quote(tag))
+	}
+
+	if fn != "" {
+		buffer.WriteString(fmt.Sprintf("  |> group(columns: [%s])\n", strings.Join(append(tags, `"_field"`), ", ")))
+	} else if len(tags) > 0 {
+		buffer.WriteString(fmt.Sprintf("  |> group(columns: [%s])\n", strings.Join(tags, ", ")))
+	}
+
+	switch {
+	case q.groupByTime != "" && fn == "":
+		return "", unsupported("GROUP BY time without an aggregate")
+	case isVariable(q.groupByTime):
+		return "", unsupported("GROUP BY %s", q.groupByTime)
+	case q.groupByTime != "":
+		every := fluxDuration(q.groupByTime)
+		fill := fmt.Sprint(q.fill)
+		buffer.WriteString(fmt.Sprintf(
+			"  |> aggregateWindow(every: %s, fn: %s, createEmpty: %t)\n",
+			every, fn, fill != "none",
+		))
+
+		switch {
+		case q.fill == nil || fill == "null" || fill == "none":
+		case fill == "previous":
+			buffer.WriteString("  |> fill(usePrevious: true)\n")
+		case fill == "linear":
+			imports = append(imports, `import "interpolate"`)
+			buffer.WriteString(fmt.Sprintf("  |> interpolate.linear(every: %s)\n", every))
+		default:
+			if _, err := strconv.ParseFloat(fill, 64); err != nil {
+				return "", unsupported("FILL(%s)", fill)
+			}
+			buffer.WriteString(fmt.Sprintf("  |> fill(value: %s)\n", fill))
+		}
+	case q.fill != nil:
+		return "", unsupported("FILL without GROUP BY time")
+	case fn != "":
+		buffer.WriteString(fmt.Sprintf("  |> %s()\n", fn))
+	}
+
+	if q.order == "DESC" {
+		buffer.WriteString("  |> sort(columns: [\"_time\"], desc: true)\n")
+	}
+
+	if q._limit {
+		if q._offset {
+			buffer.WriteString(fmt.Sprintf("  |> limit(n: %d, offset: %d)\n", q.limit, q.offset))
+		} else {
+			buffer.WriteString(fmt.Sprintf("  |> limit(n: %d)\n", q.limit))
+		}
+	} else if q._offset {
+		return "", unsupported("OFFSET without LIMIT")
+	}
+
+	flux := strings.TrimSpace(buffer.String())
+	if len(imports) > 0 {
+		flux = strings.Join(imports, "\n") + "\n\n" + flux
+	}
+
+	return flux, nil
+}
+
+// fluxFields returns the aggregate function and the fields to filter on
+func fluxFields(items []selectItem) (string, []string, error) {
+	fn := ""
+	var fields []string
+
+	for i, item := range items {
+		if item.key == "*" {
+			if item.function != "" || len(items) > 1 {
+				return "", nil, unsupported("* with other fields")
+			}
+			return "", nil, nil
+		}
+
+		name := ""
+		if item.function != "" {
+			var ok bool
+			if name, ok = fluxAggregates[item.function]; !ok {
+				return "", nil, unsupported("function %s", item.function)
+			}
+		}
+
+		if i > 0 && name != fn {
+			return "", nil, unsupported("fields with different aggregates")
+		}
+
+		fn = name
+		fields = append(fields, item.key)
+	}
+
+	return fn, fields, nil
+}
+
+// fluxRange converts time criteria into range start and stop. The start of
+// range is inclusive and its stop exclusive, so > and <= move by 1ns
+func fluxRange(times []Tag) (string, string, error) {
+	start, stop := "0", ""
+
+	for _, tag := range times {
+		var offset time.Duration
+
+		switch Operator(tag.op) {
+		case Gt, Lte:
+			offset = time.Nanosecond
+		case Gte, Lt:
+		default:
+			return "", "", unsupported("time %s", tag.op)
+		}
+
+		value, err := fluxTime(tag.value, offset)
+		if err != nil {
+			return "", "", err
+		}
+
+		switch Operator(tag.op) {
+		case Gt, Gte:
+			start = value
+		case Lt, Lte:
+			stop = value
+		}
+	}
+
+	return start, stop, nil
+}
+
+func fluxTime(value interface{}, offset time.Duration) (string, error) {
+	switch v := value.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return "", unsupported("time %s", v)
+		}
+		return t.Add(offset).UTC().Format(time.RFC3339Nano), nil
+	case time.Time:
+		return v.Add(offset).UTC().Format(time.RFC3339Nano), nil
+	case int:
+		return fmt.Sprintf("time(v: %d)", int64(v)+int64(offset)), nil
+	case int64:
+		return fmt.Sprintf("time(v: %d)", v+int64(offset)), nil
+	case uint64:
+		return fmt.Sprintf("time(v: %d)", v+uint64(offset)), nil
+	}
+
+	return "", unsupported("time value %v", value)
+}
+
+// fluxDuration converts time(10m) into 10m
+func fluxDuration(groupByTime string) string {
+	literal := strings.TrimSuffix(strings.TrimPrefix(groupByTime, "time("), ")")
+	i := strings.IndexFunc(literal, func(r rune) bool { return r < '0' || r > '9' })
+
+	if i > 0 {
+		if unit, ok := fluxDurationUnits[literal[i:]]; ok {
+			return literal[:i] + unit
+		}
+	}
+
+	return literal
+}
+
+// fluxComparison renders criteria on tag columns, and on _value for the
+// single selected field. Once filtered by _field, rows have no column named
+// after a field
+func fluxComparison(fields []string) func(Tag) (string, error) {
+	return func(tag Tag) (string, error) {
+		name, cast := splitCast(tag.key)
+		column := fmt.Sprintf("r[%s]", strconv.Quote(name))
+
+		field := cast != "" && cast != TagType
+		for _, f := range fields {
+			field = field || f == name
+		}
+
+		if field {
+			if len(fields) != 1 || fields[0] != name {
+				return "", unsupported("criteria on field %s other than the single selected field", name)
+			}
+
+			column = "r._value"
+		}
+
+		return fluxCriteria(column, tag)
+	}
+}
+
+func fluxCriteria(column string, tag Tag) (string, error) {
+
+	if re, ok := tag.value.(*regexp.Regexp); ok {
+		return fmt.Sprintf("%s %s /%s/", column, tag.op, escapeRegex(re.String())), nil
+	}
+
+	if v, ok := tag.value.(Variable); ok {
+		return "", unsupported("template variable %s", v)
+	}
+
+	op := tag.op
+	switch Operator(op) {
+	case Eq:
+		op = "=="
+	case "<>":
+		op = "!="
+	}
+
+	switch v := tag.value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%s %s %d", column, op, v), nil
+	case float32, float64:
+		return fmt.Sprintf("%s %s %s", column, op, floatLiteral(toFloat(v))), nil
+	case bool:
+		return fmt.Sprintf("%s %s %t", column, op, v), nil
+	default:
+		return fmt.Sprintf("%s %s %s", column, op, strconv.Quote(fmt.Sprintf("%s", v))), nil
+	}
+}
+
+// floatLiteral formats a float that always parses back as a float
+func floatLiteral(f float64) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+
+	return s
+}
+
+func toFloat(v interface{}) float64 {
+	switch f := v.(type) {
+	case float32:
+		return float64(f)
+	case float64:
+		return f
+	}
+
+	return 0
+}
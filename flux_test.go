@@ -0,0 +1,98 @@
+package influxquerybuilder
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestBuildFlux(t *testing.T) {
+	expected := `from(bucket: "telemetry/autogen")
+  |> range(start: 2018-11-01T06:33:57.503000001Z, stop: 2018-11-02T09:35:25Z)
+  |> filter(fn: (r) => r._measurement == "measurement")
+  |> filter(fn: (r) => r._field == "temperature")
+  |> filter(fn: (r) => r["host"] =~ /^web\// and (r["region"] == "eu" or r["cpu"] != 1))
+  |> group(columns: ["sensorId", "_field"])
+  |> aggregateWindow(every: 10m, fn: mean, createEmpty: true)
+  |> fill(usePrevious: true)
+  |> sort(columns: ["_time"], desc: true)
+  |> limit(n: 10, offset: 5)`
+	q, err := New().
+		Select(`MEAN("temperature")`).
+		From("measurement").
+		Where("time", ">", "2018-11-01T06:33:57.503Z").
+		And("host", "=~", regexp.MustCompile("^web/")).
+		And("time", "<", "2018-11-02T09:35:25Z").
+		AndBrackets(New().Where("region", "=", "eu").Or("cpu", "<>", 1)).
+		GroupByTime(NewDuration().Minute(10)).
+		GroupByTag("sensorId").
+		Fill("previous").
+		Desc().
+		Limit(10).
+		Offset(5).
+		BuildFlux("telemetry/autogen")
+
+	assert(t, err, nil)
+	assert(t, q, expected)
+}
+
+func TestBuildFluxRaw(t *testing.T) {
+	expected := `from(bucket: "telemetry")
+  |> range(start: 0)
+  |> filter(fn: (r) => r._measurement == "measurement")
+  |> filter(fn: (r) => r._field == "temperature" or r._field == "humidity")
+  |> filter(fn: (r) => r["ratio"] > 1.0)`
+	q, err := New().
+		Select("temperature", "humidity").
+		From("measurement").
+		Where("ratio", ">", 1.0).
+		BuildFlux("telemetry")
+
+	assert(t, err, nil)
+	assert(t, q, expected)
+}
+
+func TestBuildFluxFieldCriteria(t *testing.T) {
+	expected := `from(bucket: "telemetry")
+  |> range(start: 2018-11-01T00:00:00Z, stop: 2018-11-02T00:00:00.000000001Z)
+  |> filter(fn: (r) => r._measurement == "measurement")
+  |> filter(fn: (r) => r._field == "temperature")
+  |> filter(fn: (r) => r._value > 20 and r["host"] == "a")`
+	q, err := New().
+		Select("temperature").
+		From("measurement").
+		Where("time", ">=", "2018-11-01T00:00:00Z").
+		And("time", "<=", "2018-11-02T00:00:00Z").
+		And("temperature::field", ">", 20).
+		And("host::tag", "=", "a").
+		BuildFlux("telemetry")
+
+	assert(t, err, nil)
+	assert(t, q, expected)
+
+	for _, builder := range []QueryBuilder{
+		New().Select("temperature", "humidity").From("measurement").Where("temperature", ">", 20),
+		New().Select("temperature").From("measurement").Where("humidity::field", ">", 1),
+		New().Select("*").From("measurement").Where("humidity::integer", ">", 1),
+	} {
+		_, err := builder.BuildFlux("telemetry")
+		assert(t, errors.Is(err, ErrUnsupported), true)
+	}
+}
+
+func TestBuildFluxUnsupported(t *testing.T) {
+	_, err := New().Select("temperature").From("measurement").BuildFlux("")
+	assert(t, err, ErrMissingBucket)
+
+	for _, builder := range []QueryBuilder{
+		New().Select(`MEAN("a")`, `SUM("b")`).From("measurement"),
+		New().Select(`TOP("a", 3)`).From("measurement"),
+		New().Select("a").From("measurement").GroupByTime(NewDuration().Minute(1)),
+		New().Select("a").From("measurement").Where("a", "=", 1).Or("time", ">", "2018-11-01T06:33:57.503Z"),
+		New().Select("a").From("measurement").Offset(5),
+		New().Select("a").Into("b").From("measurement"),
+	} {
+		_, err := builder.BuildFlux("telemetry")
+		assert(t, errors.Is(err, ErrUnsupported), true)
+	}
+}
@@ -0,0 +1,67 @@
+package influxquerybuilder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+)
+
+// Format Response format of the /query endpoint
+type Format string
+
+// Formats
+const (
+	JSONFormat    Format = "application/json"
+	CSVFormat     Format = "application/csv"
+	MsgpackFormat Format = "application/x-msgpack"
+)
+
+// DecodeResponse Decode a response of the given content type, JSON when it
+// is empty. Numbers are json.Number whatever the format, as in Client.Execute
+func DecodeResponse(r io.Reader, contentType string) (*Response, error) {
+	format, ok := formatOf(contentType)
+	if !ok {
+		return nil, fmt.Errorf("influxquerybuilder: unsupported content type %q", contentType)
+	}
+
+	switch format {
+	case CSVFormat:
+		return decodeCSV(r)
+	case MsgpackFormat:
+		return decodeMsgpack(r)
+	}
+
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var resp Response
+	if err := decoder.Decode(&resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// formatOf Format of a content type, false for unsupported ones
+func formatOf(contentType string) (Format, bool) {
+	if contentType == "" {
+		return JSONFormat, true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+
+	switch Format(mediaType) {
+	case JSONFormat:
+		return JSONFormat, true
+	case CSVFormat, "text/csv":
+		return CSVFormat, true
+	case MsgpackFormat:
+		return MsgpackFormat, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,89 @@
+package influxquerybuilder
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func decodeGolden(t *testing.T, name string, format Format) *Response {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := DecodeResponse(bytes.NewReader(data), string(format))
+	if err != nil {
+		t.Fatalf("%s: %s", name, err)
+	}
+
+	return resp
+}
+
+func TestDecodeResponseGolden(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		formats map[string]Format
+	}{
+		{"cpu", map[string]Format{"cpu.csv": CSVFormat, "cpu.msgpack": MsgpackFormat}},
+		{"error", map[string]Format{"error.csv": CSVFormat, "error.msgpack": MsgpackFormat}},
+		{"time", map[string]Format{"time.msgpack": MsgpackFormat}},
+	} {
+		expected := decodeGolden(t, c.name+".json", JSONFormat)
+
+		for file, format := range c.formats {
+			if got := decodeGolden(t, file, format); !reflect.DeepEqual(got, expected) {
+				t.Errorf("%s: expected %+v but got %+v", file, expected, got)
+			}
+		}
+	}
+}
+
+func TestDecodeResponseContentType(t *testing.T) {
+	_, err := DecodeResponse(bytes.NewReader(nil), "text/html")
+	assert(t, err.Error(), `influxquerybuilder: unsupported content type "text/html"`)
+
+	resp, err := DecodeResponse(bytes.NewReader([]byte(`{"results":[]}`)), "application/json; charset=utf-8")
+	assert(t, err, nil)
+	assert(t, len(resp.Results), 0)
+}
+
+func TestClientFormat(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "cpu.msgpack"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != string(MsgpackFormat) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", string(MsgpackFormat))
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	client := &Client{URL: server.URL, Database: "telegraf", Epoch: "ns"}
+
+	resp, err := client.WithFormat(MsgpackFormat).Execute(context.Background(), `SELECT * FROM "cpu"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, reflect.DeepEqual(resp, decodeGolden(t, "cpu.json", JSONFormat)), true)
+
+	resp, err = client.Execute(context.Background(), `SELECT * FROM "cpu"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, len(resp.Results[0].Series), 0)
+}
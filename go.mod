@@ -0,0 +1,3 @@
+module github.com/benjamin658/influx-query-builder
+
+go 1.23
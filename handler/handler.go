@@ -0,0 +1,335 @@
+// Package handler provides an http.Handler that turns JSON query specs into
+// InfluxQL, checked against an allowlist and optionally executed.
+//
+// Requests are POSTed as JSON:
+//
+//	{"spec": {"version": 1, "fields": ["usage_idle"], "measurement": "cpu", ...}, "execute": true}
+//
+// and answered with the built query and, when executed, the results:
+//
+//	{"query": "SELECT \"usage_idle\" FROM \"cpu\" ...", "results": [...]}
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+// Config Allowlist and backend of the handler
+type Config struct {
+	// Measurements Allowed measurements and their allowed fields, "*" allows
+	// every field of a measurement
+	Measurements map[string][]string
+	// MaxTimeRange Maximum time range of a query, 0 disables the check
+	MaxTimeRange time.Duration
+	// MaxLimit Maximum LIMIT of a query, 0 disables the check
+	MaxLimit uint
+	// Executor Optional backend executing the built queries
+	Executor qb.Executor
+	// Now Current time of open ended time ranges, defaults to time.Now
+	Now func() time.Time
+}
+
+// Request Request body
+type Request struct {
+	Spec    qb.QuerySpec `json:"spec"`
+	Execute bool         `json:"execute,omitempty"`
+}
+
+// Response Response body
+type Response struct {
+	Query   string      `json:"query,omitempty"`
+	Results []qb.Result `json:"results,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// maxBody Maximum size of a request body
+const maxBody = 1 << 20
+
+// errForbidden Errors of allowlist violations
+var errForbidden = errors.New("forbidden")
+
+type handler struct {
+	config Config
+}
+
+// New New http.Handler
+func New(config Config) http.Handler {
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+
+	return &handler{config: config}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, Response{Error: "method not allowed"})
+		return
+	}
+
+	var req Request
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+
+	if err := dec.Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, Response{Error: err.Error()})
+		return
+	}
+
+	if err := h.allow(req.Spec); err != nil {
+		writeJSON(w, http.StatusForbidden, Response{Error: err.Error()})
+		return
+	}
+
+	q, err := qb.NewFromSpec(req.Spec)
+	if err == nil {
+		err = q.Validate()
+	}
+
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, Response{Error: err.Error()})
+		return
+	}
+
+	resp := Response{Query: q.Build()}
+
+	if !req.Execute {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	if h.config.Executor == nil {
+		resp.Error = "execution is not configured"
+		writeJSON(w, http.StatusNotImplemented, resp)
+		return
+	}
+
+	result, err := h.config.Executor.Execute(r.Context(), resp.Query)
+	if err == nil {
+		err = result.Error()
+	}
+
+	if err != nil {
+		resp.Error = err.Error()
+		writeJSON(w, http.StatusBadGateway, resp)
+		return
+	}
+
+	resp.Results = result.Results
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// identifier Identifiers the allowlist accepts
+const identifier = `[A-Za-z_][\w.-]*`
+
+// reference A field reference within a call, quoted or not, or *
+const reference = `"` + identifier + `"|` + identifier + `|\*`
+
+var (
+	// identMatcher Retention policies and GROUP BY tags, which the builder
+	// renders unquoted
+	identMatcher = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+	keyMatcher   = regexp.MustCompile(`^(` + identifier + `)(?:::\w+)?$`)
+	varMatcher   = regexp.MustCompile(`^\w+$`)
+	// fieldMatcher The forms the builder renders: an unquoted field with an
+	// optional cast, *, or one call on a field, with an optional alias such as
+	// MEAN("usage_idle") AS idle or usage_idle::float AS idle
+	fieldMatcher = regexp.MustCompile(`^(?:\w+\(\s*(` + reference + `)(?:::\w+)?\s*\)|(` + identifier + `)(?:::\w+)?|(\*))(?: AS \w+)?$`)
+)
+
+// fills FILL options besides numbers
+var fills = map[string]bool{"null": true, "none": true, "previous": true, "linear": true}
+
+// allow checks a spec against the allowlist. Identifiers, fields, FILL and
+// variables are restricted to plain forms so that nothing but the allowed
+// measurements and fields can be read
+func (h *handler) allow(spec qb.QuerySpec) error {
+	fields, ok := h.config.Measurements[spec.Measurement]
+	if !ok {
+		return fmt.Errorf("%w: measurement %q", errForbidden, spec.Measurement)
+	}
+
+	allowed := map[string]bool{}
+	for _, f := range fields {
+		allowed[f] = true
+	}
+
+	for _, field := range spec.Fields {
+		// The builder splits fields on AS, it may only appear in the alias
+		match := fieldMatcher.FindStringSubmatch(strings.TrimSpace(field))
+		if match == nil || strings.Count(field, "AS") != strings.Count(field, " AS ") {
+			return fmt.Errorf("%w: field %q", errForbidden, field)
+		}
+
+		name := strings.Trim(match[1]+match[2]+match[3], `"`)
+		if !allowed["*"] && !allowed[name] {
+			return fmt.Errorf("%w: field %q", errForbidden, name)
+		}
+	}
+
+	if spec.RetentionPolicy != "" && !identMatcher.MatchString(spec.RetentionPolicy) {
+		return fmt.Errorf("%w: retention policy %q", errForbidden, spec.RetentionPolicy)
+	}
+
+	if spec.Into != "" || spec.IntoRetentionPolicy != "" {
+		return fmt.Errorf("%w: INTO", errForbidden)
+	}
+
+	if err := allowCriteria(spec.Criteria, allowed); err != nil {
+		return err
+	}
+
+	if strings.Contains(spec.GroupByTime, "$") {
+		return fmt.Errorf("%w: group by time %q", errForbidden, spec.GroupByTime)
+	}
+
+	for _, tag := range spec.GroupByTags {
+		if tag == "*" && allowed["*"] {
+			continue
+		}
+
+		if !identMatcher.MatchString(tag) || !allowed["*"] && !allowed[tag] {
+			return fmt.Errorf("%w: group by tag %q", errForbidden, tag)
+		}
+	}
+
+	if err := allowFill(spec.Fill); err != nil {
+		return err
+	}
+
+	if h.config.MaxLimit > 0 && (spec.Limit == nil || *spec.Limit > h.config.MaxLimit) {
+		return fmt.Errorf("%w: limit must be set and at most %d", errForbidden, h.config.MaxLimit)
+	}
+
+	if h.config.MaxTimeRange > 0 {
+		return h.allowTimeRange(spec.Criteria)
+	}
+
+	return nil
+}
+
+// allowCriteria checks the keys, values and variables of criteria and their
+// brackets
+func allowCriteria(c *qb.CriteriaSpec, allowed map[string]bool) error {
+	if c == nil {
+		return nil
+	}
+
+	conditions := append(append([]qb.ConditionSpec{}, c.And...), c.Or...)
+	if c.Where != nil {
+		conditions = append(conditions, *c.Where)
+	}
+
+	for _, cond := range conditions {
+		if cond.Var != "" && !varMatcher.MatchString(cond.Var) {
+			return fmt.Errorf("%w: variable %q", errForbidden, cond.Var)
+		}
+
+		switch cond.Value.(type) {
+		case nil, string, bool, json.Number, float64:
+		default:
+			return fmt.Errorf("%w: criteria value %v", errForbidden, cond.Value)
+		}
+
+		// A variable without key is a whole criteria such as $timeFilter
+		if cond.Var != "" && cond.Key == "" && cond.Op == "" {
+			continue
+		}
+
+		match := keyMatcher.FindStringSubmatch(cond.Key)
+		if match == nil || match[1] != "time" && !allowed["*"] && !allowed[match[1]] {
+			return fmt.Errorf("%w: criteria key %q", errForbidden, cond.Key)
+		}
+	}
+
+	brackets := append(append([]qb.CriteriaSpec{}, c.AndBrackets...), c.OrBrackets...)
+	if c.WhereBrackets != nil {
+		brackets = append(brackets, *c.WhereBrackets)
+	}
+
+	for i := range brackets {
+		if err := allowCriteria(&brackets[i], allowed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// allowFill accepts the FILL options and numbers
+func allowFill(fill interface{}) error {
+	switch v := fill.(type) {
+	case nil, json.Number, float64:
+		return nil
+	case string:
+		if fills[strings.ToLower(v)] {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: fill %v", errForbidden, fill)
+}
+
+// allowTimeRange requires a lower time bound from top level AND criteria
+// within MaxTimeRange of the upper bound, or of now without one
+func (h *handler) allowTimeRange(c *qb.CriteriaSpec) error {
+	var start, stop time.Time
+
+	if c != nil && len(c.Or) == 0 && len(c.OrBrackets) == 0 {
+		conditions := append([]qb.ConditionSpec{}, c.And...)
+		if c.Where != nil {
+			conditions = append(conditions, *c.Where)
+		}
+
+		for _, cond := range conditions {
+			if cond.Key != "time" {
+				continue
+			}
+
+			s, _ := cond.Value.(string)
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return fmt.Errorf("%w: time must be an RFC3339 string", errForbidden)
+			}
+
+			switch qb.Operator(cond.Op) {
+			case qb.Gt, qb.Gte:
+				start = t
+			case qb.Lt, qb.Lte:
+				stop = t
+			}
+		}
+	}
+
+	if start.IsZero() {
+		return fmt.Errorf("%w: time range must have a lower bound", errForbidden)
+	}
+
+	if stop.IsZero() {
+		stop = h.config.Now()
+	}
+
+	if stop.Sub(start) > h.config.MaxTimeRange {
+		return fmt.Errorf("%w: time range exceeds %s", errForbidden, h.config.MaxTimeRange)
+	}
+
+	return nil
+}
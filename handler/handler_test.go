@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+type fakeExecutor struct {
+	query string
+}
+
+func (f *fakeExecutor) Execute(ctx context.Context, query string) (*qb.Response, error) {
+	f.query = query
+
+	return &qb.Response{Results: []qb.Result{{Series: []qb.Series{{
+		Name:    "cpu",
+		Columns: []string{"time", "usage_idle"},
+		Values:  [][]interface{}{{"2018-11-01T00:00:00Z", 99.5}},
+	}}}}}, nil
+}
+
+func newTestServer(executor qb.Executor) *httptest.Server {
+	return httptest.NewServer(New(Config{
+		Measurements: map[string][]string{"cpu": {"usage_idle", "host"}, "mem": {"*"}},
+		MaxTimeRange: 24 * time.Hour,
+		MaxLimit:     1000,
+		Executor:     executor,
+		Now:          func() time.Time { return time.Date(2018, 11, 2, 0, 0, 0, 0, time.UTC) },
+	}))
+}
+
+func post(t *testing.T, server *httptest.Server, body string) (int, Response) {
+	res, err := http.Post(server.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var resp Response
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	return res.StatusCode, resp
+}
+
+const validSpec = `{
+	"version": 1,
+	"fields": ["MEAN(\"usage_idle\") AS idle"],
+	"measurement": "cpu",
+	"criteria": {
+		"where": {"key": "time", "op": ">", "value": "2018-11-01T06:00:00Z"},
+		"and": [{"key": "host", "op": "=~", "regex": "^web"}]
+	},
+	"groupByTime": "1h",
+	"limit": 100
+}`
+
+func TestBuild(t *testing.T) {
+	server := newTestServer(nil)
+	defer server.Close()
+
+	status, resp := post(t, server, `{"spec": `+validSpec+`}`)
+
+	if status != http.StatusOK {
+		t.Fatalf("Expected 200 but got %d: %s", status, resp.Error)
+	}
+
+	expected := `SELECT MEAN("usage_idle") AS "idle" FROM "cpu" WHERE "time" > '2018-11-01T06:00:00Z' AND "host" =~ /^web/ GROUP BY time(1h) LIMIT 100`
+	if resp.Query != expected {
+		t.Errorf("Expected %s but got %s", expected, resp.Query)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	executor := &fakeExecutor{}
+	server := newTestServer(executor)
+	defer server.Close()
+
+	status, resp := post(t, server, `{"spec": `+validSpec+`, "execute": true}`)
+
+	if status != http.StatusOK || executor.query != resp.Query || resp.Results[0].Series[0].Name != "cpu" {
+		t.Errorf("Unexpected %d %+v", status, resp)
+	}
+
+	unconfigured := newTestServer(nil)
+	defer unconfigured.Close()
+
+	if status, _ := post(t, unconfigured, `{"spec": `+validSpec+`, "execute": true}`); status != http.StatusNotImplemented {
+		t.Errorf("Expected 501 but got %d", status)
+	}
+}
+
+func TestAllowlist(t *testing.T) {
+	server := newTestServer(nil)
+	defer server.Close()
+
+	for _, spec := range []string{
+		strings.Replace(validSpec, `"measurement": "cpu"`, `"measurement": "disk"`, 1),
+		strings.Replace(validSpec, `usage_idle`, `usage_user`, 1),
+		strings.Replace(validSpec, `"limit": 100`, `"limit": 5000`, 1),
+		strings.Replace(validSpec, `,
+	"limit": 100`, ``, 1),
+		strings.Replace(validSpec, `2018-11-01T06:00:00Z`, `2018-10-01T06:00:00Z`, 1),
+		strings.Replace(validSpec, `"where": {"key": "time"`, `"where": {"key": "host"`, 1),
+		strings.Replace(validSpec, `"and"`, `"or"`, 1),
+		strings.Replace(validSpec, `"measurement": "cpu"`, `"measurement": "cpu", "into": "copy"`, 1),
+	} {
+		status, resp := post(t, server, `{"spec": `+spec+`}`)
+		if status != http.StatusForbidden {
+			t.Errorf("Expected 403 but got %d %+v for %s", status, resp, spec)
+		}
+	}
+
+	status, resp := post(t, server, `{"spec": `+strings.Replace(validSpec, `"measurement": "cpu"`, `"measurement": "mem"`, 1)+`}`)
+	if status != http.StatusOK {
+		t.Errorf("Expected 200 but got %d %+v", status, resp)
+	}
+}
+
+func TestAllowlistInjection(t *testing.T) {
+	server := newTestServer(nil)
+	defer server.Close()
+
+	for _, spec := range []string{
+		strings.Replace(validSpec, `"measurement": "cpu"`, `"measurement": "cpu", "retentionPolicy": "x;DROP DATABASE \"prod\";SELECT * FROM y"`, 1),
+		strings.Replace(validSpec, `"groupByTime": "1h"`, `"groupByTime": "1h", "groupByTags": ["host;DROP DATABASE \"prod\""]`, 1),
+		strings.Replace(validSpec, `"groupByTime": "1h"`, `"groupByTime": "1h", "groupByTags": ["region"]`, 1),
+		strings.Replace(validSpec, `"groupByTime": "1h"`, `"groupByTime": "$i) FROM y;DROP DATABASE prod;SELECT (1"`, 1),
+		strings.Replace(validSpec, `"groupByTime": "1h"`, `"groupByTime": "1h", "fill": "0);DROP DATABASE \"prod\";SELECT (1"`, 1),
+		strings.Replace(validSpec, `MEAN(\"usage_idle\") AS idle`, `MEAN(\"usage_idle\"),MEAN(\"secret\")`, 1),
+		strings.Replace(validSpec, `MEAN(\"usage_idle\") AS idle`, `MEAN(\"usage_idle\") FROM y;DROP DATABASE \"prod\"`, 1),
+		strings.Replace(validSpec, `MEAN(\"usage_idle\") AS idle`, `MAX(MEAN(\"secret\"))`, 1),
+		strings.Replace(validSpec, `{"key": "host", "op": "=~", "regex": "^web"}`, `{"key": "host\" = 'a' OR \"x", "op": "=", "value": "b"}`, 1),
+		strings.Replace(validSpec, `{"key": "host", "op": "=~", "regex": "^web"}`, `{"key": "host::tag = 'a' OR x", "op": "=", "value": "b"}`, 1),
+		strings.Replace(validSpec, `{"key": "host", "op": "=~", "regex": "^web"}`, `{"key": "host", "op": "=", "var": "h OR time > 0"}`, 1),
+		strings.Replace(validSpec, `"and": [{"key": "host", "op": "=~", "regex": "^web"}]`, `"andBrackets": [{"where": {"key": "secret", "op": "=", "value": 1}}]`, 1),
+		strings.Replace(validSpec, `"measurement": "cpu"`, `"measurement": "cpu", "retentionPolicy": "telegraf.autogen"`, 1),
+		strings.Replace(validSpec, `MEAN(\"usage_idle\") AS idle`, `MEAN(\"usage_idle\")::float AS idle`, 1),
+		strings.Replace(validSpec, `MEAN(\"usage_idle\") AS idle`, `\"usage_idle\"`, 1),
+		strings.Replace(validSpec, `MEAN(\"usage_idle\") AS idle`, `MEAN(\"usage_idle\") AS ASx`, 1),
+		strings.Replace(validSpec, `{"key": "host", "op": "=~", "regex": "^web"}`, `{"key": "host", "op": "=", "value": {"a": 1}}`, 1),
+		strings.Replace(validSpec, `{"key": "host", "op": "=~", "regex": "^web"}`, `{"key": "host", "op": "=", "value": ["a"]}`, 1),
+	} {
+		status, resp := post(t, server, `{"spec": `+spec+`}`)
+		if status != http.StatusForbidden {
+			t.Errorf("Expected 403 but got %d %+v for %s", status, resp, spec)
+		}
+	}
+
+	spec := strings.Replace(validSpec, `MEAN(\"usage_idle\") AS idle`, `usage_idle::float AS idle`, 1)
+	status, resp := post(t, server, `{"spec": `+spec+`}`)
+
+	expected := `SELECT "usage_idle"::float AS "idle" FROM "cpu" WHERE "time" > '2018-11-01T06:00:00Z' AND "host" =~ /^web/ GROUP BY time(1h) LIMIT 100`
+	if status != http.StatusOK || resp.Query != expected {
+		t.Errorf("Expected %s but got %d %+v", expected, status, resp)
+	}
+
+	spec = strings.Replace(validSpec, `"groupByTime": "1h"`, `"retentionPolicy": "autogen", "groupByTime": "1h", "groupByTags": ["host"], "fill": 0`, 1)
+	status, resp = post(t, server, `{"spec": `+spec+`}`)
+
+	expected = `SELECT MEAN("usage_idle") AS "idle" FROM autogen."cpu" WHERE "time" > '2018-11-01T06:00:00Z' AND "host" =~ /^web/ GROUP BY time(1h),host FILL(0) LIMIT 100`
+	if status != http.StatusOK || resp.Query != expected {
+		t.Errorf("Expected %s but got %d %+v", expected, status, resp)
+	}
+}
+
+func TestBadRequest(t *testing.T) {
+	server := newTestServer(nil)
+	defer server.Close()
+
+	for _, body := range []string{
+		`not json`,
+		`{"spec": ` + strings.Replace(validSpec, `"version": 1`, `"version": 9`, 1) + `}`,
+		`{"spec": ` + strings.Replace(validSpec, `"op": "=~"`, `"op": "=="`, 1) + `}`,
+		`{"spec": ` + validSpec + `, "padding": "` + strings.Repeat("x", maxBody) + `"}`,
+	} {
+		if status, _ := post(t, server, body); status != http.StatusBadRequest {
+			t.Errorf("Expected 400 but got %d for %s", status, body)
+		}
+	}
+
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 but got %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,309 @@
+// Package influxtest provides a fake InfluxDB 1.x server for integration
+// tests, answering /query, /write and /ping from a memdb store:
+//
+//	server := influxtest.NewServer()
+//	defer server.Close()
+//
+//	client := &qb.Client{URL: server.URL, Database: "telegraf"}
+//	server.Inject(influxtest.Fault{Status: http.StatusServiceUnavailable})
+//
+// Every query string is recorded. Faults are consumed one per request, in
+// order, to simulate timeouts, error statuses and partial results.
+package influxtest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	qb "github.com/benjamin658/influx-query-builder"
+	"github.com/benjamin658/influx-query-builder/memdb"
+)
+
+// Version Version reported by /ping
+const Version = "1.8.10"
+
+// defaultChunkSize Rows per chunk of chunked queries without chunk_size
+const defaultChunkSize = 10000
+
+// Fault Error injected into a request
+type Fault struct {
+	// Delay Wait before answering, longer than a client timeout to simulate
+	// timeouts. The wait ends early when the client goes away
+	Delay time.Duration
+	// Status Answer with this status and Err instead of handling the request
+	Status int
+	// Err Error message of the body, the status text when empty
+	Err string
+	// PartialRows Truncate every series of a query to that many rows and
+	// mark its result partial
+	PartialRows int
+}
+
+// Server Fake InfluxDB server
+type Server struct {
+	*httptest.Server
+	// DB Store of /query and /write, databases and retention policies are
+	// ignored
+	DB *memdb.DB
+	// Now Time of points written without timestamp, defaults to time.Now
+	Now func() time.Time
+
+	mu      sync.Mutex
+	queries []string
+	faults  []Fault
+}
+
+// NewServer New started Server, to be closed by the caller
+func NewServer() *Server {
+	s := &Server{DB: memdb.New()}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", s.ping)
+	mux.HandleFunc("/query", s.query)
+	mux.HandleFunc("/write", s.write)
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Queries Query strings received so far, in order
+func (s *Server) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.queries...)
+}
+
+// Inject Queue faults, each one is applied to a single request
+func (s *Server) Inject(faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, faults...)
+}
+
+// Reset Forget recorded queries and pending faults, the data is kept
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queries = nil
+	s.faults = nil
+}
+
+// fault applies the next fault, false when the request has been answered
+func (s *Server) fault(w http.ResponseWriter, r *http.Request) (Fault, bool) {
+	s.mu.Lock()
+	var f Fault
+	if len(s.faults) > 0 {
+		f, s.faults = s.faults[0], s.faults[1:]
+	}
+	s.mu.Unlock()
+
+	if f.Delay > 0 {
+		timer := time.NewTimer(f.Delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return f, false
+		}
+	}
+
+	if f.Status != 0 {
+		message := f.Err
+		if message == "" {
+			message = http.StatusText(f.Status)
+		}
+
+		writeJSON(w, f.Status, qb.Response{Err: message})
+		return f, false
+	}
+
+	return f, true
+}
+
+func (s *Server) ping(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.fault(w, r); !ok {
+		return
+	}
+
+	w.Header().Set("X-Influxdb-Build", "OSS")
+	w.Header().Set("X-Influxdb-Version", Version)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) query(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		writeJSON(w, http.StatusMethodNotAllowed, qb.Response{Err: "method not allowed"})
+		return
+	}
+
+	q := r.FormValue("q")
+
+	s.mu.Lock()
+	s.queries = append(s.queries, q)
+	s.mu.Unlock()
+
+	f, ok := s.fault(w, r)
+	if !ok {
+		return
+	}
+
+	if q == "" {
+		writeJSON(w, http.StatusBadRequest, qb.Response{Err: `missing required parameter "q"`})
+		return
+	}
+
+	epoch, ok := precisions[r.FormValue("epoch")]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, qb.Response{Err: "invalid epoch " + r.FormValue("epoch")})
+		return
+	}
+
+	resp, err := s.DB.Execute(r.Context(), q)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, qb.Response{Err: err.Error()})
+		return
+	}
+
+	if resp.Err != "" {
+		writeJSON(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	for i := range resp.Results {
+		result := &resp.Results[i]
+
+		for j := range result.Series {
+			series := &result.Series[j]
+
+			if f.PartialRows > 0 && len(series.Values) > f.PartialRows {
+				series.Values = series.Values[:f.PartialRows]
+				result.Partial = true
+			}
+
+			if r.FormValue("epoch") != "" {
+				toEpoch(series.Values, epoch)
+			}
+		}
+	}
+
+	if r.FormValue("chunked") != "true" {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	size := defaultChunkSize
+	if n, err := strconv.Atoi(r.FormValue("chunk_size")); err == nil && n > 0 {
+		size = n
+	}
+
+	writeChunks(w, resp, size)
+}
+
+func (s *Server) write(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, qb.Response{Err: "method not allowed"})
+		return
+	}
+
+	if _, ok := s.fault(w, r); !ok {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, qb.Response{Err: err.Error()})
+		return
+	}
+
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+
+	points, err := ParseLines(string(body), r.URL.Query().Get("precision"), now())
+	if err == nil {
+		err = s.DB.Write(points...)
+	}
+
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, qb.Response{Err: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// toEpoch replaces the RFC3339 times of rows with integers of the precision
+func toEpoch(values [][]interface{}, precision time.Duration) {
+	for _, row := range values {
+		s, _ := row[0].(string)
+
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			continue
+		}
+
+		row[0] = json.Number(strconv.FormatInt(t.UnixNano()/int64(precision), 10))
+	}
+}
+
+// writeChunks writes a response as InfluxDB does for chunked=true: one JSON
+// document per chunk of at most size rows of a series. Series and results
+// are partial while more of their rows follow
+func writeChunks(w http.ResponseWriter, resp *qb.Response, size int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	write := func(result qb.Result) {
+		encoder.Encode(qb.Response{Results: []qb.Result{result}})
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	for _, result := range resp.Results {
+		if len(result.Series) == 0 {
+			write(result)
+			continue
+		}
+
+		for i, series := range result.Series {
+			for start := 0; start == 0 || start < len(series.Values); start += size {
+				end := min(start+size, len(series.Values))
+
+				chunk := series
+				chunk.Values = series.Values[start:end]
+				chunk.Partial = end < len(series.Values)
+
+				write(qb.Result{
+					StatementID: result.StatementID,
+					Series:      []qb.Series{chunk},
+					Messages:    result.Messages,
+					Partial:     chunk.Partial || i < len(result.Series)-1 || result.Partial,
+				})
+			}
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
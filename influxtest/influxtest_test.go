@@ -0,0 +1,175 @@
+package influxtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+const lines = `cpu,host=a value=1 1577836800000000000
+cpu,host=a value=2 1577836860000000000
+cpu,host=b value=3 1577836800000000000
+`
+
+func newTestServer(t *testing.T) *Server {
+	server := NewServer()
+
+	res, err := http.Post(server.URL+"/write?db=telegraf", "text/plain", strings.NewReader(lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected 204 but got %d", res.StatusCode)
+	}
+
+	return server
+}
+
+func TestQuery(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	client := &qb.Client{URL: server.URL, Database: "telegraf"}
+	query := qb.New().Select("value").From("cpu").WhereEq("host", "a").Build()
+
+	resp, err := client.Execute(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, resp.Results[0].Series[0].Values, [][]interface{}{
+		{"2020-01-01T00:00:00Z", json.Number("1")},
+		{"2020-01-01T00:01:00Z", json.Number("2")},
+	})
+
+	client.Epoch = "s"
+
+	resp, err = client.Execute(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, resp.Results[0].Series[0].Values[0][0], json.Number("1577836800"))
+	assert(t, server.Queries(), []string{query, query})
+
+	if _, err := client.Execute(context.Background(), "SELECT FROM"); err == nil {
+		t.Error("Expected a parse error")
+	}
+}
+
+func TestStream(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	client := &qb.Client{URL: server.URL}
+
+	decoder, err := client.Stream(context.Background(), `SELECT "value" FROM "cpu" GROUP BY "host"`, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer decoder.Close()
+
+	var partial []bool
+	for row, err := range decoder.Rows(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		partial = append(partial, row.Partial)
+	}
+
+	assert(t, partial, []bool{true, false, false})
+}
+
+func TestPing(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	assert(t, res.StatusCode, http.StatusNoContent)
+	assert(t, res.Header.Get("X-Influxdb-Version"), Version)
+}
+
+func TestWriteErrors(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	for _, url := range []string{"/write", "/write?precision=d"} {
+		res, err := http.Post(server.URL+url, "text/plain", strings.NewReader("cpu value="))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		assert(t, res.StatusCode, http.StatusBadRequest)
+	}
+
+	res, err := http.Get(server.URL + "/write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	assert(t, res.StatusCode, http.StatusMethodNotAllowed)
+	assert(t, server.DB.Measurements(), []string{})
+}
+
+func TestFaults(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	client := &qb.Client{URL: server.URL}
+	query := `SELECT "value" FROM "cpu"`
+
+	server.Inject(
+		Fault{Status: http.StatusServiceUnavailable, Err: "overloaded"},
+		Fault{Status: http.StatusUnauthorized},
+		Fault{PartialRows: 1},
+	)
+
+	if _, err := client.Execute(context.Background(), query); err == nil || !strings.Contains(err.Error(), "503 Service Unavailable: overloaded") {
+		t.Errorf("Unexpected %v", err)
+	}
+
+	if _, err := client.Execute(context.Background(), query); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Unexpected %v", err)
+	}
+
+	resp, err := client.Execute(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, resp.Results[0].Partial, true)
+	assert(t, len(resp.Results[0].Series[0].Values), 1)
+
+	server.Inject(Fault{Delay: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.Execute(ctx, query); err == nil {
+		t.Error("Expected a timeout")
+	}
+
+	assert(t, len(server.Queries()), 4)
+
+	server.Reset()
+	assert(t, len(server.Queries()), 0)
+
+	if _, err := client.Execute(context.Background(), query); err != nil {
+		t.Error(err)
+	}
+}
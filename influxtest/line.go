@@ -0,0 +1,179 @@
+package influxtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benjamin658/influx-query-builder/memdb"
+)
+
+// precisions Durations of the precision and epoch parameters
+var precisions = map[string]time.Duration{
+	"":   time.Nanosecond,
+	"n":  time.Nanosecond,
+	"ns": time.Nanosecond,
+	"u":  time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// ParseLines Parse line protocol, points without timestamp are at now
+func ParseLines(body string, precision string, now time.Time) ([]memdb.Point, error) {
+	unit, ok := precisions[precision]
+	if !ok {
+		return nil, fmt.Errorf("invalid precision %q", precision)
+	}
+
+	var points []memdb.Point
+
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		p, err := parseLine(line, unit, now)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse '%s': %w", line, err)
+		}
+
+		points = append(points, p)
+	}
+
+	return points, nil
+}
+
+func parseLine(line string, unit time.Duration, now time.Time) (memdb.Point, error) {
+	p := memdb.Point{Time: now}
+
+	key, rest, ok := cut(line, ' ', false)
+	if !ok {
+		return p, fmt.Errorf("missing fields")
+	}
+
+	fieldSet, timestamp, _ := cut(rest, ' ', true)
+
+	tags := split(key, ',', false)
+	p.Measurement = unescape(tags[0])
+	if p.Measurement == "" {
+		return p, fmt.Errorf("missing measurement")
+	}
+
+	for _, t := range tags[1:] {
+		k, v, ok := cut(t, '=', false)
+		if !ok || k == "" || v == "" {
+			return p, fmt.Errorf("invalid tag %s", t)
+		}
+
+		if p.Tags == nil {
+			p.Tags = map[string]string{}
+		}
+		p.Tags[unescape(k)] = unescape(v)
+	}
+
+	if fieldSet == "" {
+		return p, fmt.Errorf("missing fields")
+	}
+
+	p.Fields = map[string]interface{}{}
+
+	for _, f := range split(fieldSet, ',', true) {
+		k, v, ok := cut(f, '=', true)
+		if !ok || k == "" || v == "" {
+			return p, fmt.Errorf("invalid field %s", f)
+		}
+
+		value, err := fieldValue(v)
+		if err != nil {
+			return p, err
+		}
+
+		p.Fields[unescape(k)] = value
+	}
+
+	if timestamp = strings.TrimSpace(timestamp); timestamp != "" {
+		n, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return p, fmt.Errorf("bad timestamp")
+		}
+
+		p.Time = time.Unix(0, n*int64(unit))
+	}
+
+	return p, nil
+}
+
+// fieldValue value of a field: "string", 1i, 1u, true or a float
+func fieldValue(v string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		if len(v) < 2 || !strings.HasSuffix(v, `"`) {
+			return nil, fmt.Errorf("unbalanced quotes")
+		}
+
+		return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(v[1 : len(v)-1]), nil
+	case strings.HasSuffix(v, "i"):
+		return strconv.ParseInt(v[:len(v)-1], 10, 64)
+	case strings.HasSuffix(v, "u"):
+		n, err := strconv.ParseUint(v[:len(v)-1], 10, 64)
+		return int64(n), err
+	}
+
+	switch v {
+	case "t", "T", "true", "True", "TRUE":
+		return true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return false, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %s", v)
+	}
+
+	return f, nil
+}
+
+// split splits on sep outside of escapes and, when quoted, double quotes
+func split(s string, sep byte, quoted bool) []string {
+	var parts []string
+
+	for {
+		before, after, ok := cut(s, sep, quoted)
+		parts = append(parts, before)
+
+		if !ok {
+			return parts
+		}
+
+		s = after
+	}
+}
+
+// cut cuts around the first sep outside of escapes and, when quoted, double
+// quotes
+func cut(s string, sep byte, quoted bool) (string, string, bool) {
+	inQuote := false
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			i++
+		case quoted && c == '"':
+			inQuote = !inQuote
+		case !inQuote && c == sep:
+			return s[:i], s[i+1:], true
+		}
+	}
+
+	return s, "", false
+}
+
+// unescape removes the backslashes of escaped commas, equal signs and spaces
+func unescape(s string) string {
+	return strings.NewReplacer(`\,`, `,`, `\=`, `=`, `\ `, ` `).Replace(s)
+}
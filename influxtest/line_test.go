@@ -0,0 +1,71 @@
+package influxtest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/benjamin658/influx-query-builder/memdb"
+)
+
+func assert(t *testing.T, got interface{}, expected interface{}) {
+	t.Helper()
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected\n%v\nbut got\n%v", expected, got)
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	points, err := ParseLines(`
+# comment
+cpu,host=a,region=eu\ west value=1.5,count=2i,up=t,state="say \"hi\", ok" 1577836800
+disk\,io,path=/var\=x free=3u
+`, "s", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, points, []memdb.Point{
+		{
+			Measurement: "cpu",
+			Tags:        map[string]string{"host": "a", "region": "eu west"},
+			Fields:      map[string]interface{}{"value": 1.5, "count": int64(2), "up": true, "state": `say "hi", ok`},
+			Time:        time.Unix(1577836800, 0),
+		},
+		{
+			Measurement: "disk,io",
+			Tags:        map[string]string{"path": "/var=x"},
+			Fields:      map[string]interface{}{"free": int64(3)},
+			Time:        now,
+		},
+	})
+
+	points, err = ParseLines("cpu value=1 1577836800000", "ms", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, points[0].Time.Equal(now), true)
+}
+
+func TestParseLinesErrors(t *testing.T) {
+	for _, line := range []string{
+		"cpu",
+		"cpu,host value=1",
+		",host=a value=1",
+		"cpu value=",
+		"cpu value=abc",
+		`cpu value="open`,
+		"cpu value=1 yesterday",
+	} {
+		if _, err := ParseLines(line, "", time.Now()); err == nil {
+			t.Errorf("Expected an error for %s", line)
+		}
+	}
+
+	if _, err := ParseLines("cpu value=1", "d", time.Now()); err == nil {
+		t.Error("Expected an invalid precision error")
+	}
+}
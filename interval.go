@@ -0,0 +1,139 @@
+package influxquerybuilder
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+)
+
+// niceIntervals GROUP BY time intervals AutoInterval picks from, longer
+// intervals are whole weeks
+var niceIntervals = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	15 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	day,
+	week,
+}
+
+// durationLiteralUnits Units of duration literals, largest first
+var durationLiteralUnits = []struct {
+	unit string
+	size time.Duration
+}{
+	{"w", week},
+	{"d", day},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+	{"ms", time.Millisecond},
+	{"u", time.Microsecond},
+	{"ns", time.Nanosecond},
+}
+
+// DurationOf Duration of a time.Duration, in the largest exact unit
+func DurationOf(d time.Duration) Duration {
+	if d <= 0 {
+		return NewDuration()
+	}
+
+	for _, u := range durationLiteralUnits {
+		if d%u.size == 0 {
+			return durationUnits[u.unit](NewDuration(), uint(d/u.size))
+		}
+	}
+
+	return NewDuration().Nanoseconds(uint(d))
+}
+
+// toTimeDuration time.Duration of a Duration, false for INF and variables
+func toTimeDuration(d Duration) (time.Duration, bool) {
+	if d == nil || !d.isSet() || d.isInfinite() {
+		return 0, false
+	}
+
+	literal := d.getLiteral()
+	i := strings.IndexFunc(literal, func(r rune) bool { return r < '0' || r > '9' })
+	if i <= 0 {
+		return 0, false
+	}
+
+	value, err := strconv.ParseInt(literal[:i], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	for _, u := range durationLiteralUnits {
+		if u.unit == literal[i:] {
+			return time.Duration(value) * u.size, true
+		}
+	}
+
+	return 0, false
+}
+
+// AutoInterval Nice GROUP BY time interval (1s, 5s, 10s, 1m, 5m, ...) giving at
+// most maxPoints points between start and end
+func AutoInterval(start, end time.Time, maxPoints int) Duration {
+	return AutoIntervalMin(start, end, maxPoints, 0)
+}
+
+// AutoIntervalMin AutoInterval that is never shorter than min, a min between
+// two nice intervals rounds up to the longer one
+func AutoIntervalMin(start, end time.Time, maxPoints int, min time.Duration) Duration {
+	if maxPoints < 1 {
+		maxPoints = 1
+	}
+
+	span := end.Sub(start)
+	if span < 0 {
+		span = -span
+	}
+
+	interval := (span + time.Duration(maxPoints) - 1) / time.Duration(maxPoints)
+	if interval < min {
+		interval = min
+	}
+
+	for _, nice := range niceIntervals {
+		if nice >= interval {
+			return DurationOf(nice)
+		}
+	}
+
+	weeks := (interval + week - 1) / week
+
+	return NewDuration().Week(uint(weeks))
+}
+
+// AutoGroupByTime WHERE time >= start AND time <= end, grouped by the
+// AutoIntervalMin interval. The time criteria are ANDed to existing criteria,
+// which are bracketed first when they contain OR
+func (q *Query) AutoGroupByTime(start, end time.Time, maxPoints int, min time.Duration) QueryBuilder {
+	q.bracketOr()
+	q.addCriteria("time", Gte, formatTime(start))
+	q.addCriteria("time", Lte, formatTime(end))
+
+	return q.GroupByTime(AutoIntervalMin(start, end, maxPoints, min))
+}
+
+// formatTime formats a time as an RFC3339 criteria value
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
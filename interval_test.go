@@ -0,0 +1,101 @@
+package influxquerybuilder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDurationOf(t *testing.T) {
+	for d, expected := range map[time.Duration]string{
+		90 * time.Second:        "90s",
+		2 * time.Hour:           "2h",
+		14 * day:                "2w",
+		3 * day:                 "3d",
+		1500 * time.Millisecond: "1500ms",
+		time.Microsecond:        "1u",
+		7:                       "7ns",
+	} {
+		assert(t, DurationOf(d).getLiteral(), expected)
+	}
+
+	assert(t, DurationOf(0).isSet(), false)
+}
+
+func TestToTimeDuration(t *testing.T) {
+	d, ok := toTimeDuration(NewDuration().Minute(10))
+	assert(t, ok, true)
+	assert(t, d, 10*time.Minute)
+
+	_, ok = toTimeDuration(NewDuration().Infinite())
+	assert(t, ok, false)
+
+	_, ok = toTimeDuration(IntervalVar())
+	assert(t, ok, false)
+}
+
+func TestAutoInterval(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, c := range []struct {
+		span      time.Duration
+		maxPoints int
+		expected  string
+	}{
+		{time.Minute, 1000, "1s"},
+		{time.Hour, 1000, "5s"},
+		{time.Hour, 60, "1m"},
+		{6 * time.Hour, 100, "5m"},
+		{24 * time.Hour, 1000, "5m"},
+		{7 * day, 500, "30m"},
+		{365 * day, 100, "1w"},
+		{365 * day, 10, "6w"},
+		{2 * time.Hour, 0, "3h"},
+	} {
+		assert(t, AutoInterval(start, start.Add(c.span), c.maxPoints).getLiteral(), c.expected)
+	}
+
+	assert(t, AutoInterval(start.Add(time.Hour), start, 60).getLiteral(), "1m")
+}
+
+func TestAutoIntervalMin(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert(t, AutoIntervalMin(start, start.Add(time.Minute), 1000, 10*time.Second).getLiteral(), "10s")
+	assert(t, AutoIntervalMin(start, start.Add(time.Minute), 1000, 20*time.Second).getLiteral(), "30s")
+	assert(t, AutoIntervalMin(start, start.Add(time.Hour), 60, time.Second).getLiteral(), "1m")
+}
+
+func TestAutoGroupByTime(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	expected := `SELECT MEAN("value") FROM "cpu" WHERE "time" >= '2020-01-01T00:00:00Z' AND "time" <= '2020-01-01T06:00:00Z' GROUP BY time(5m)`
+	q := New().
+		Select(`MEAN("value")`).
+		From("cpu").
+		AutoGroupByTime(start, start.Add(6*time.Hour), 100, 0).
+		Build()
+
+	assert(t, q, expected)
+
+	expected = `SELECT MEAN("value") FROM "cpu" WHERE "host" = 'a' AND "time" >= '2020-01-01T00:00:00Z' AND "time" <= '2020-01-01T00:01:00Z' GROUP BY time(10s),host`
+	q = New().
+		Select(`MEAN("value")`).
+		From("cpu").
+		Where("host", "=", "a").
+		AutoGroupByTime(start, start.Add(time.Minute), 1000, 10*time.Second).
+		GroupByTag("host").
+		Build()
+
+	assert(t, q, expected)
+
+	expected = `SELECT MEAN("value") FROM "cpu" WHERE ("host" = 'a' OR "host" = 'b') AND "time" >= '2020-01-01T00:00:00Z' AND "time" <= '2020-01-01T00:01:00Z' GROUP BY time(10s)`
+	q = New().
+		Select(`MEAN("value")`).
+		From("cpu").
+		Where("host", "=", "a").
+		Or("host", "=", "b").
+		AutoGroupByTime(start, start.Add(time.Minute), 1000, 10*time.Second).
+		Build()
+
+	assert(t, q, expected)
+}
@@ -0,0 +1,57 @@
+package influxquerybuilder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KeyType Type hint of a key, rendered as "key"::type
+type KeyType string
+
+// Key types
+const (
+	TagType     KeyType = "tag"
+	FieldType   KeyType = "field"
+	IntegerType KeyType = "integer"
+	FloatType   KeyType = "float"
+	StringType  KeyType = "string"
+	BooleanType KeyType = "boolean"
+)
+
+// TagKey Tag key, "key"::tag, criteria values are compared as strings
+func TagKey(name string) string {
+	return Cast(name, TagType)
+}
+
+// FieldKey Field key, "key"::field
+func FieldKey(name string) string {
+	return Cast(name, FieldType)
+}
+
+// Cast Key with a type hint, e.g. Cast("value", IntegerType) for "value"::integer
+func Cast(name string, t KeyType) string {
+	return fmt.Sprintf("%s::%s", name, t)
+}
+
+// splitCast splits a key into its name and a known type hint
+func splitCast(key string) (string, KeyType) {
+	i := strings.LastIndex(key, "::")
+	if i < 0 {
+		return key, ""
+	}
+
+	switch t := KeyType(key[i+2:]); t {
+	case TagType, FieldType, IntegerType, FloatType, StringType, BooleanType:
+		return key[:i], t
+	}
+
+	return key, ""
+}
+
+func quoteKey(name string, t KeyType) string {
+	if t == "" {
+		return quoteIdent(name)
+	}
+
+	return fmt.Sprintf(`%s::%s`, quoteIdent(name), t)
+}
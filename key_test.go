@@ -0,0 +1,38 @@
+package influxquerybuilder
+
+import (
+	"testing"
+)
+
+func TestSelectCast(t *testing.T) {
+	expected := `SELECT "value"::field,"host"::tag,"usage"::integer AS "u" FROM "measurement"`
+	q := New().
+		Select(FieldKey("value"), TagKey("host"), Cast("usage", IntegerType)+" AS u").
+		From("measurement").
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestWhereTagKey(t *testing.T) {
+	expected := `SELECT "value" FROM "measurement" WHERE "cpu"::tag = '0' AND "value"::field > 10 OR "ratio"::float < 0.5`
+	q := New().
+		Select("value").
+		From("measurement").
+		Where(TagKey("cpu"), "=", 0).
+		And(FieldKey("value"), ">", 10).
+		Or(Cast("ratio", FloatType), "<", 0.5).
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestSplitCast(t *testing.T) {
+	name, cast := splitCast("host::tag")
+	assert(t, name, "host")
+	assert(t, cast, TagType)
+
+	name, cast = splitCast("a::b")
+	assert(t, name, "a::b")
+	assert(t, cast, KeyType(""))
+}
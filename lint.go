@@ -0,0 +1,271 @@
+package influxquerybuilder
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Severity Severity of a lint finding
+type Severity string
+
+// Severities
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Lint rule IDs
+const (
+	RuleMissingTimeRange      = "missing-time-range"
+	RuleSelectAllGroupAll     = "select-all-group-all"
+	RuleRegexHighCardinality  = "regex-high-cardinality"
+	RuleLargeOffset           = "large-offset"
+	RuleFillLinearWithoutTime = "fill-linear-without-time"
+	RuleLimitWithoutOrder     = "limit-without-order"
+	RuleDeepOrChain           = "deep-or-chain"
+)
+
+// Lint defaults
+const (
+	DefaultMaxOffset = 10000
+	DefaultMaxOr     = 20
+)
+
+// LintRule A lint rule
+type LintRule struct {
+	ID          string
+	Severity    Severity
+	Description string
+}
+
+// LintRules Every rule of the linter
+var LintRules = []LintRule{
+	{RuleMissingTimeRange, SeverityWarning, "no lower time bound, every shard is scanned"},
+	{RuleSelectAllGroupAll, SeverityWarning, "SELECT * with GROUP BY * returns every field of every series"},
+	{RuleRegexHighCardinality, SeverityWarning, "regex on a high cardinality tag scans its whole index"},
+	{RuleLargeOffset, SeverityWarning, "large OFFSET reads and discards every skipped point"},
+	{RuleFillLinearWithoutTime, SeverityError, "fill(linear) needs GROUP BY time"},
+	{RuleLimitWithoutOrder, SeverityInfo, "LIMIT without ORDER BY relies on the default time order"},
+	{RuleDeepOrChain, SeverityWarning, "long OR chains are evaluated point by point"},
+}
+
+// Finding A lint finding
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Message  string
+}
+
+// String "severity rule: message"
+func (f Finding) String() string {
+	return fmt.Sprintf("%s %s: %s", f.Severity, f.Rule, f.Message)
+}
+
+// Linter Query linter, the zero value runs every rule with the defaults
+type Linter struct {
+	// HighCardinalityTags Tags of RuleRegexHighCardinality
+	HighCardinalityTags []string
+	// MaxOffset Largest OFFSET, DefaultMaxOffset when 0
+	MaxOffset uint
+	// MaxOr Largest number of OR operators, DefaultMaxOr when 0
+	MaxOr int
+	// Disabled IDs of the rules to skip
+	Disabled []string
+}
+
+// Lint Lint a query with the default Linter
+func Lint(builder QueryBuilder) []Finding {
+	return Linter{}.Lint(builder)
+}
+
+// Lint Findings of the enabled rules, in the order of LintRules
+func (l Linter) Lint(builder QueryBuilder) []Finding {
+	q, ok := builder.(*Query)
+	if !ok {
+		q = &Query{}
+		if _, err := q.FromSpec(builder.ToSpec()); err != nil {
+			return nil
+		}
+	}
+
+	checks := map[string]func(*Query) []string{
+		RuleMissingTimeRange:      l.missingTimeRange,
+		RuleSelectAllGroupAll:     l.selectAllGroupAll,
+		RuleRegexHighCardinality:  l.regexHighCardinality,
+		RuleLargeOffset:           l.largeOffset,
+		RuleFillLinearWithoutTime: l.fillLinearWithoutTime,
+		RuleLimitWithoutOrder:     l.limitWithoutOrder,
+		RuleDeepOrChain:           l.deepOrChain,
+	}
+
+	var findings []Finding
+
+	for _, rule := range LintRules {
+		if l.disabled(rule.ID) {
+			continue
+		}
+
+		for _, message := range checks[rule.ID](q) {
+			findings = append(findings, Finding{Rule: rule.ID, Severity: rule.Severity, Message: message})
+		}
+	}
+
+	return findings
+}
+
+func (l Linter) disabled(id string) bool {
+	for _, d := range l.Disabled {
+		if d == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l Linter) missingTimeRange(q *Query) []string {
+	// $timeFilter stands for the dashboard time range
+	for _, tag := range append([]Tag{q.where}, q.and...) {
+		if _, ok := tag.value.(Variable); ok && tag.key == "" && q.or == nil && q.orBrackets == nil {
+			return nil
+		}
+	}
+
+	_, times, err := q.withoutTimeCriteria()
+	if err != nil {
+		return []string{"time criteria under OR do not bound the scan"}
+	}
+
+	for _, tag := range times {
+		switch Operator(tag.op) {
+		case Gt, Gte, Eq:
+			return nil
+		}
+	}
+
+	return []string{fmt.Sprintf("query of %q has no lower time bound", q.measurement)}
+}
+
+func (l Linter) selectAllGroupAll(q *Query) []string {
+	all := len(q.fields) == 0
+	for _, f := range q.fields {
+		if name, _ := splitCast(strings.TrimSpace(f)); name == "*" {
+			all = true
+		}
+	}
+
+	for _, tag := range q.groupByTags {
+		if tag == "*" && all {
+			return []string{"SELECT * with GROUP BY *"}
+		}
+	}
+
+	return nil
+}
+
+func (l Linter) regexHighCardinality(q *Query) []string {
+	var messages []string
+
+	for _, tag := range criteriaTags(q.GetQueryStruct()) {
+		name, _ := splitCast(tag.key)
+		if !Operator(tag.op).IsRegex() || !contains(l.HighCardinalityTags, name) {
+			continue
+		}
+
+		switch v := tag.value.(type) {
+		case *regexp.Regexp:
+			messages = append(messages, fmt.Sprintf("regex /%s/ on high cardinality tag %q", v, name))
+		case Variable:
+			messages = append(messages, fmt.Sprintf("regex %s on high cardinality tag %q", v, name))
+		}
+	}
+
+	return messages
+}
+
+func (l Linter) largeOffset(q *Query) []string {
+	limit := l.MaxOffset
+	if limit == 0 {
+		limit = DefaultMaxOffset
+	}
+
+	if q._offset && q.offset > limit {
+		return []string{fmt.Sprintf("OFFSET %d is larger than %d, page with time criteria instead", q.offset, limit)}
+	}
+
+	return nil
+}
+
+func (l Linter) fillLinearWithoutTime(q *Query) []string {
+	if fill, ok := q.fill.(string); ok && strings.EqualFold(fill, "linear") && q.groupByTime == "" {
+		return []string{"fill(linear) without GROUP BY time"}
+	}
+
+	return nil
+}
+
+func (l Linter) limitWithoutOrder(q *Query) []string {
+	if q._limit && q.order == "" {
+		return []string{fmt.Sprintf("LIMIT %d without ORDER BY time", q.limit)}
+	}
+
+	return nil
+}
+
+func (l Linter) deepOrChain(q *Query) []string {
+	limit := l.MaxOr
+	if limit == 0 {
+		limit = DefaultMaxOr
+	}
+
+	if n := countOr(q.GetQueryStruct()); n > limit {
+		return []string{fmt.Sprintf("%d OR operators, more than %d", n, limit)}
+	}
+
+	return nil
+}
+
+// criteriaTags tags of the criteria and of the brackets
+func criteriaTags(q CurrentQuery) []Tag {
+	tags := append([]Tag{q.Where}, q.And...)
+	tags = append(tags, q.Or...)
+
+	for _, b := range criteriaBrackets(q) {
+		if b != nil {
+			tags = append(tags, criteriaTags(b.GetQueryStruct())...)
+		}
+	}
+
+	return tags
+}
+
+// countOr OR operators of the criteria and of the brackets
+func countOr(q CurrentQuery) int {
+	n := len(q.Or) + len(q.OrBrackets)
+
+	for _, b := range criteriaBrackets(q) {
+		if b != nil {
+			n += countOr(b.GetQueryStruct())
+		}
+	}
+
+	return n
+}
+
+// criteriaBrackets brackets of the criteria, the first is nil without WHERE brackets
+func criteriaBrackets(q CurrentQuery) []QueryBuilder {
+	b := append([]QueryBuilder{q.WhereBrackets}, q.AndBrackets...)
+	return append(b, q.OrBrackets...)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
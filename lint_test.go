@@ -0,0 +1,91 @@
+package influxquerybuilder
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func rules(findings []Finding) string {
+	ids := make([]string, len(findings))
+	for i, f := range findings {
+		ids[i] = f.Rule
+	}
+
+	return strings.Join(ids, ",")
+}
+
+func TestLint(t *testing.T) {
+	findings := Lint(New().
+		Select("usage_idle").
+		From("cpu").
+		Where("time", ">", "2018-11-01T00:00:00Z").
+		And("host", "=", "a").
+		GroupByTime(NewDuration().Minute(10)).
+		Fill("linear").
+		Limit(10).
+		Desc())
+
+	assert(t, len(findings), 0)
+}
+
+func TestLintRules(t *testing.T) {
+	base := func() QueryBuilder {
+		return New().Select("usage_idle").From("cpu").Where("time", ">=", "2018-11-01T00:00:00Z")
+	}
+
+	hosts := make([]interface{}, 25)
+	for i := range hosts {
+		hosts[i] = i
+	}
+
+	for _, c := range []struct {
+		builder  QueryBuilder
+		expected string
+	}{
+		{New().Select("usage_idle").From("cpu"), RuleMissingTimeRange},
+		{New().Select("usage_idle").From("cpu").Where("time", "<", "2018-11-01T00:00:00Z"), RuleMissingTimeRange},
+		{New().Select("usage_idle").From("cpu").Where("time", ">", "2018-11-01T00:00:00Z").OrEq("host", "a"), RuleMissingTimeRange},
+		{New().Select("usage_idle").From("cpu").WhereVar(TimeFilter()).AndEq("host", "a"), ""},
+		{base().Select("*").GroupByTag("*"), RuleSelectAllGroupAll},
+		{base().Select("*").GroupByTag("host"), ""},
+		{base().And("host", "=~", regexp.MustCompile("^web")), RuleRegexHighCardinality},
+		{base().And("host", "=~", Var("host")), RuleRegexHighCardinality},
+		{base().AndBrackets(New().Where("container_id", "!~", regexp.MustCompile("x"))), RuleRegexHighCardinality},
+		{base().And("region", "=~", regexp.MustCompile("^eu")), ""},
+		{base().Offset(DefaultMaxOffset + 1), RuleLargeOffset},
+		{base().Offset(DefaultMaxOffset), ""},
+		{base().Fill("linear"), RuleFillLinearWithoutTime},
+		{base().Limit(10), RuleLimitWithoutOrder},
+		{base().Limit(10).Asc(), ""},
+		{base().AndIn("host", hosts...), RuleDeepOrChain},
+		{base().AndIn("host", hosts[:21]...), ""},
+	} {
+		linter := Linter{HighCardinalityTags: []string{"host", "container_id"}}
+		assert(t, rules(linter.Lint(c.builder)), c.expected)
+	}
+}
+
+func TestLintDisabled(t *testing.T) {
+	builder := New().Select("*").From("cpu").GroupByTag("*").Limit(10).Offset(200)
+
+	assert(t, rules(Lint(builder)), RuleMissingTimeRange+","+RuleSelectAllGroupAll+","+RuleLimitWithoutOrder)
+
+	linter := Linter{MaxOffset: 100, Disabled: []string{RuleMissingTimeRange, RuleLimitWithoutOrder}}
+	findings := linter.Lint(builder)
+
+	assert(t, rules(findings), RuleSelectAllGroupAll+","+RuleLargeOffset)
+	assert(t, findings[1].String(), "warning large-offset: OFFSET 200 is larger than 100, page with time criteria instead")
+}
+
+func TestLintParsed(t *testing.T) {
+	q, err := Parse(`SELECT * FROM "cpu" GROUP BY * FILL(linear)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings := Lint(q)
+
+	assert(t, rules(findings), RuleMissingTimeRange+","+RuleSelectAllGroupAll+","+RuleFillLinearWithoutTime)
+	assert(t, findings[2].Severity, SeverityError)
+}
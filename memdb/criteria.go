@@ -0,0 +1,336 @@
+package memdb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+// predicate Compiled criteria
+type predicate func(Point) bool
+
+// term A criteria and the keyword joining it to the previous one
+type term struct {
+	or        bool
+	condition *qb.ConditionSpec
+	brackets  *qb.CriteriaSpec
+}
+
+// terms Criteria in the order the builder renders them: where, and, or,
+// and brackets, or brackets
+func terms(c *qb.CriteriaSpec) []term {
+	var ts []term
+
+	if c.Where != nil {
+		ts = append(ts, term{condition: c.Where})
+	} else if c.WhereBrackets != nil {
+		ts = append(ts, term{brackets: c.WhereBrackets})
+	} else {
+		return nil
+	}
+
+	for i := range c.And {
+		ts = append(ts, term{condition: &c.And[i]})
+	}
+
+	for i := range c.Or {
+		ts = append(ts, term{or: true, condition: &c.Or[i]})
+	}
+
+	for i := range c.AndBrackets {
+		ts = append(ts, term{brackets: &c.AndBrackets[i]})
+	}
+
+	for i := range c.OrBrackets {
+		ts = append(ts, term{or: true, brackets: &c.OrBrackets[i]})
+	}
+
+	return ts
+}
+
+// compileCriteria compiles criteria with InfluxQL precedence, AND before OR
+func compileCriteria(c *qb.CriteriaSpec) (predicate, error) {
+	if c == nil {
+		return func(Point) bool { return true }, nil
+	}
+
+	ts := terms(c)
+	if ts == nil {
+		return func(Point) bool { return true }, nil
+	}
+
+	var groups [][]predicate
+
+	for _, t := range ts {
+		var p predicate
+		var err error
+
+		if t.condition != nil {
+			p, err = compileCondition(*t.condition)
+		} else {
+			p, err = compileCriteria(t.brackets)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if t.or || groups == nil {
+			groups = append(groups, []predicate{p})
+		} else {
+			groups[len(groups)-1] = append(groups[len(groups)-1], p)
+		}
+	}
+
+	return func(point Point) bool {
+		for _, group := range groups {
+			matched := true
+			for _, p := range group {
+				if !p(point) {
+					matched = false
+					break
+				}
+			}
+
+			if matched {
+				return true
+			}
+		}
+
+		return false
+	}, nil
+}
+
+func compileCondition(c qb.ConditionSpec) (predicate, error) {
+	if c.Var != "" {
+		return nil, fmt.Errorf("template variable $%s", c.Var)
+	}
+
+	name, cast := splitCast(c.Key)
+	op := qb.Operator(c.Op)
+
+	if !op.Valid() {
+		return nil, fmt.Errorf("invalid operator %s", c.Op)
+	}
+
+	if name == "time" {
+		t, ok := timeOf(c.Value)
+		if !ok || op.IsRegex() {
+			return nil, fmt.Errorf("invalid time criteria %s %v", c.Op, c.Value)
+		}
+
+		return func(p Point) bool {
+			return compare(op, p.Time.Compare(t))
+		}, nil
+	}
+
+	if c.Regex != "" {
+		re, err := regexp.Compile(c.Regex)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(p Point) bool {
+			s, ok := stringOf(p, name, cast)
+			return ok && re.MatchString(s) == (op == qb.Match)
+		}, nil
+	}
+
+	if op.IsRegex() {
+		return nil, fmt.Errorf("%s %s needs a regex", c.Key, c.Op)
+	}
+
+	return func(p Point) bool {
+		if v, ok := p.Fields[name]; ok && cast != qb.TagType {
+			if cast == qb.StringType {
+				return compare(op, strings.Compare(fmt.Sprint(v), fmt.Sprint(c.Value)))
+			}
+
+			r, ok := compareValues(v, c.Value)
+			return ok && (r == 0 || op != qb.Eq) && compare(op, r)
+		}
+
+		if cast == qb.FieldType {
+			return false
+		}
+
+		// Tags compare as strings, a missing tag is empty
+		return compare(op, strings.Compare(p.Tags[name], fmt.Sprint(c.Value)))
+	}, nil
+}
+
+// stringOf string value of a tag or string field
+func stringOf(p Point, name string, cast qb.KeyType) (string, bool) {
+	if v, ok := p.Fields[name]; ok && cast != qb.TagType {
+		s, ok := v.(string)
+		return s, ok
+	}
+
+	if cast == qb.FieldType {
+		return "", false
+	}
+
+	return p.Tags[name], true
+}
+
+// compare applies an operator to the result of a comparison
+func compare(op qb.Operator, r int) bool {
+	switch op {
+	case qb.Eq:
+		return r == 0
+	case qb.Neq, "<>":
+		return r != 0
+	case qb.Lt:
+		return r < 0
+	case qb.Lte:
+		return r <= 0
+	case qb.Gt:
+		return r > 0
+	case qb.Gte:
+		return r >= 0
+	}
+
+	return false
+}
+
+// compareValues compares a field value with a criteria value of the same kind
+func compareValues(a, b interface{}) (int, bool) {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+
+		return 0, true
+	}
+
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		return strings.Compare(x, y), ok
+	case bool:
+		y, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+
+		if x == y {
+			return 0, true
+		}
+
+		// Booleans are only equal or not, never ordered
+		return 2, true
+	}
+
+	return 0, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case interface{ Float64() (float64, error) }:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+// timeOf time of a criteria value, RFC3339 strings or epoch nanoseconds
+func timeOf(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		return parsed, err == nil
+	case time.Time:
+		return t, true
+	case int:
+		return time.Unix(0, int64(t)).UTC(), true
+	case int64:
+		return time.Unix(0, t).UTC(), true
+	case interface{ Int64() (int64, error) }:
+		// Integral json.Number, float64 would round epoch nanoseconds
+		if n, err := t.Int64(); err == nil {
+			return time.Unix(0, n).UTC(), true
+		}
+	}
+
+	if f, ok := toFloat(v); ok {
+		return time.Unix(0, int64(f)).UTC(), true
+	}
+
+	return time.Time{}, false
+}
+
+// timeBounds inclusive time range of top level AND criteria, zero when open
+func timeBounds(c *qb.CriteriaSpec) (time.Time, time.Time) {
+	var start, end time.Time
+
+	if c == nil {
+		return start, end
+	}
+
+	for _, t := range terms(c) {
+		if t.or {
+			return time.Time{}, time.Time{}
+		}
+
+		if t.condition == nil {
+			continue
+		}
+
+		name, _ := splitCast(t.condition.Key)
+		v, ok := timeOf(t.condition.Value)
+		if name != "time" || !ok {
+			continue
+		}
+
+		switch qb.Operator(t.condition.Op) {
+		case qb.Gt:
+			v = v.Add(time.Nanosecond)
+			fallthrough
+		case qb.Gte:
+			if start.IsZero() || v.After(start) {
+				start = v
+			}
+		case qb.Lt:
+			v = v.Add(-time.Nanosecond)
+			fallthrough
+		case qb.Lte:
+			if end.IsZero() || v.Before(end) {
+				end = v
+			}
+		}
+	}
+
+	return start, end
+}
+
+// splitCast splits "key"::type into its name and type hint. The builder has
+// its own unexported helper for rendering keys, this one also strips quotes
+// written around the name and takes any type hint, as keys are only matched
+// against points
+func splitCast(key string) (string, qb.KeyType) {
+	name, cast := key, qb.KeyType("")
+
+	if i := strings.LastIndex(key, "::"); i >= 0 {
+		name, cast = key[:i], qb.KeyType(key[i+2:])
+	}
+
+	return strings.Trim(name, `"`), cast
+}
@@ -0,0 +1,137 @@
+package memdb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+func TestCriteria(t *testing.T) {
+	db := newTestDB(t)
+
+	for where, expected := range map[string]string{
+		`"host" = 'a'`:                                     `[[1],[3]]`,
+		`"host" != 'a'`:                                    `[[2],[4]]`,
+		`"host" =~ /^b/`:                                   `[[2],[4]]`,
+		`"host" !~ /^b/`:                                   `[[1],[3]]`,
+		`"value" > 2`:                                      `[[3],[4]]`,
+		`"count" <= 2`:                                     `[[1],[2]]`,
+		`"missing" = 'x'`:                                  `null`,
+		`"host"::field = 'a'`:                              `null`,
+		`time > '2020-01-01T00:01:00Z'`:                    `[[3],[4]]`,
+		`time <= '2020-01-01T00:01:00Z'`:                   `[[1],[2]]`,
+		`"host" = 'a' AND "value" > 1 OR "value" = 4`:      `[[3],[4]]`,
+		`"value" = 4 OR "host" = 'a' AND "value" > 1`:      `[[3],[4]]`,
+		`("value" = 4 OR "host" = 'a') AND "value" > 1`:    `[[3],[4]]`,
+		`"region" = 'us' AND ("value" = 1 OR "value" = 2)`: `[[2]]`,
+	} {
+		resp, err := db.Execute(ctx(), `SELECT "value" FROM "cpu" WHERE `+where)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := resp.Results[0].Error(); err != nil {
+			t.Fatalf("%s: %v", where, err)
+		}
+
+		var values [][]interface{}
+		for _, s := range resp.Results[0].Series {
+			for _, row := range s.Values {
+				values = append(values, row[1:])
+			}
+		}
+
+		assert(t, toJSON(t, values), expected)
+	}
+}
+
+func TestCriteriaTypes(t *testing.T) {
+	db := newTestDB(t)
+
+	for where, expected := range map[string]bool{
+		`"free" = 10`:         true,
+		`"free" = 10.0`:       true,
+		`"free" > 9.5`:        true,
+		`"free" = '10'`:       false,
+		`"ok" = true`:         true,
+		`"ok" != false`:       true,
+		`"ok" = false`:        false,
+		`"state" = 'up'`:      true,
+		`"state" =~ /^u/`:     true,
+		`"state" < 'v'`:       true,
+		`"state"::tag = 'up'`: false,
+		`"host"::tag = 'a'`:   true,
+	} {
+		resp, err := db.Execute(ctx(), `SELECT "free" FROM "mem" WHERE `+where)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := resp.Results[0].Error(); err != nil {
+			t.Fatalf("%s: %v", where, err)
+		}
+
+		assert(t, len(resp.Results[0].Series) == 1, expected)
+	}
+}
+
+func TestCriteriaErrors(t *testing.T) {
+	for _, builder := range []qb.QueryBuilder{
+		qb.New().Select("value").From("cpu").WhereVar(qb.TimeFilter()),
+		qb.New().Select("value").From("cpu").Where("time", "=~", "x"),
+		qb.New().Select("value").From("cpu").Where("time", ">", "yesterday"),
+	} {
+		if _, err := compileCriteria(builder.ToSpec().Criteria); err == nil {
+			t.Errorf("Expected an error for %s", builder.Build())
+		}
+	}
+}
+
+func TestTimeBounds(t *testing.T) {
+	start, end := timeBounds(qb.New().
+		Where("time", ">", "2020-01-01T00:00:00Z").
+		And("time", ">=", "2019-01-01T00:00:00Z").
+		And("time", "<", "2020-01-02T00:00:00Z").
+		AndEq("host", "a").
+		ToSpec().Criteria)
+
+	assert(t, start, t0.Add(time.Nanosecond))
+	assert(t, end, t0.Add(24*time.Hour-time.Nanosecond))
+
+	start, end = timeBounds(qb.New().
+		Where("time", ">", "2020-01-01T00:00:00Z").
+		OrEq("host", "a").
+		ToSpec().Criteria)
+
+	assert(t, start.IsZero() && end.IsZero(), true)
+}
+
+func TestTimeOf(t *testing.T) {
+	// Above 2^53, float64 rounds epoch nanoseconds
+	const ns = 1577836800000000001
+
+	for _, v := range []interface{}{int64(ns), int(ns), json.Number("1577836800000000001")} {
+		got, ok := timeOf(v)
+		assert(t, ok, true)
+		assert(t, got.UnixNano(), int64(ns))
+	}
+
+	got, ok := timeOf(json.Number("1.5"))
+	assert(t, ok, true)
+	assert(t, got.UnixNano(), int64(1))
+
+	_, ok = timeOf(true)
+	assert(t, ok, false)
+}
+
+func TestSplitCast(t *testing.T) {
+	name, cast := splitCast(`"host"::tag`)
+	assert(t, name, "host")
+	assert(t, cast, qb.TagType)
+
+	name, cast = splitCast("value")
+	assert(t, name, "value")
+	assert(t, cast, qb.KeyType(""))
+}
@@ -0,0 +1,689 @@
+package memdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+// column Selected field, fn is the lowercased function of aggregates
+type column struct {
+	name string
+	fn   string
+	key  string
+	cast qb.KeyType
+}
+
+// bucket Aggregated row, empty when no point fell into it
+type bucket struct {
+	time   time.Time
+	empty  bool
+	values []interface{}
+}
+
+var (
+	callMatcher     = regexp.MustCompile(`^(\w+)\(\s*("[^"]*"|\w+)(::\w+)?\s*\)$`)
+	intervalMatcher = regexp.MustCompile(`^(\d+)(ns|u|µ|ms|s|m|h|d|w)$`)
+)
+
+var intervalUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"u":  time.Microsecond,
+	"µ":  time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+var functions = map[string]bool{
+	"mean":  true,
+	"sum":   true,
+	"count": true,
+	"min":   true,
+	"max":   true,
+	"first": true,
+	"last":  true,
+}
+
+var selectors = map[string]bool{
+	"min":   true,
+	"max":   true,
+	"first": true,
+	"last":  true,
+}
+
+func (db *DB) evaluate(spec qb.QuerySpec) (qb.Result, error) {
+	result := qb.Result{}
+
+	if spec.Into != "" {
+		return result, fmt.Errorf("SELECT INTO is not supported")
+	}
+
+	if spec.Measurement == "" {
+		return result, fmt.Errorf("missing measurement")
+	}
+
+	columns, aggregate, err := parseColumns(spec.Fields)
+	if err != nil {
+		return result, err
+	}
+
+	match, err := compileCriteria(spec.Criteria)
+	if err != nil {
+		return result, err
+	}
+
+	interval, err := parseInterval(spec.GroupByTime)
+	if err != nil {
+		return result, err
+	}
+
+	if interval > 0 && !aggregate {
+		return result, fmt.Errorf("GROUP BY requires at least one aggregate function")
+	}
+
+	var points []Point
+	for _, p := range db.snapshot(spec.Measurement) {
+		if match(p) {
+			points = append(points, p)
+		}
+	}
+
+	if len(points) == 0 {
+		return result, nil
+	}
+
+	groupTags := groupByTags(spec.GroupByTags, points)
+
+	if !aggregate {
+		columns = expandWildcard(columns, points, groupTags)
+	}
+
+	names := []string{"time"}
+	for _, c := range columns {
+		names = append(names, c.name)
+	}
+
+	start, end := timeBounds(spec.Criteria)
+
+	for _, s := range splitSeries(points, groupTags) {
+		var rows [][]interface{}
+
+		if aggregate {
+			rows, err = aggregateRows(columns, s.points, interval, points, start, end, spec.Fill)
+			if err != nil {
+				return qb.Result{}, err
+			}
+		} else {
+			rows = rawRows(columns, s.points)
+		}
+
+		rows = paginate(rows, spec)
+		if len(rows) == 0 {
+			continue
+		}
+
+		result.Series = append(result.Series, qb.Series{
+			Name:    spec.Measurement,
+			Tags:    s.tags,
+			Columns: names,
+			Values:  rows,
+		})
+	}
+
+	return result, nil
+}
+
+// parseColumns parses the selected fields, mixing raw fields and aggregates
+// is an error as in InfluxDB
+func parseColumns(fields []string) ([]column, bool, error) {
+	if len(fields) == 0 {
+		fields = []string{"*"}
+	}
+
+	var columns []column
+	aggregates := 0
+	seen := map[string]int{}
+
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		alias := ""
+
+		if i := strings.LastIndex(strings.ToUpper(f), " AS "); i >= 0 {
+			f, alias = strings.TrimSpace(f[:i]), strings.Trim(strings.TrimSpace(f[i+4:]), `"`)
+		}
+
+		c := column{}
+
+		if match := callMatcher.FindStringSubmatch(f); match != nil {
+			c.fn = strings.ToLower(match[1])
+			if !functions[c.fn] {
+				return nil, false, fmt.Errorf("unsupported function %s", match[1])
+			}
+
+			c.key = strings.Trim(match[2], `"`)
+			c.cast = qb.KeyType(strings.TrimPrefix(match[3], "::"))
+			c.name = c.fn
+			aggregates++
+		} else if f == "*" {
+			c.key = "*"
+		} else {
+			c.key, c.cast = splitCast(f)
+			c.name = c.key
+		}
+
+		if alias != "" {
+			c.name = alias
+		}
+
+		// Duplicate names get a suffix, mean and mean_1
+		if n := seen[c.name]; n > 0 && c.key != "*" {
+			seen[c.name]++
+			c.name = fmt.Sprintf("%s_%d", c.name, n)
+		} else {
+			seen[c.name]++
+		}
+
+		columns = append(columns, c)
+	}
+
+	if aggregates > 0 && aggregates < len(columns) {
+		return nil, false, fmt.Errorf("mixing aggregate and non-aggregate queries is not supported")
+	}
+
+	return columns, aggregates > 0, nil
+}
+
+// parseInterval duration of a GROUP BY time interval, 0 without one
+func parseInterval(literal string) (time.Duration, error) {
+	if literal == "" {
+		return 0, nil
+	}
+
+	match := intervalMatcher.FindStringSubmatch(literal)
+	if match == nil {
+		return 0, fmt.Errorf("unsupported GROUP BY time(%s)", literal)
+	}
+
+	n, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid GROUP BY time(%s)", literal)
+	}
+
+	return time.Duration(n) * intervalUnits[match[2]], nil
+}
+
+// groupByTags tags of the series, * is every tag of the points
+func groupByTags(tags []string, points []Point) []string {
+	all := false
+	var keys []string
+
+	for _, t := range tags {
+		if t == "*" {
+			all = true
+			continue
+		}
+
+		keys = append(keys, strings.Trim(t, `"`))
+	}
+
+	if !all {
+		return keys
+	}
+
+	set := map[string]bool{}
+	for _, k := range keys {
+		set[k] = true
+	}
+
+	for _, p := range points {
+		for k := range p.Tags {
+			if !set[k] {
+				set[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// expandWildcard replaces * by the fields and tags of the points, sorted,
+// tags of the GROUP BY are left out
+func expandWildcard(columns []column, points []Point, groupTags []string) []column {
+	var expanded []column
+
+	for _, c := range columns {
+		if c.key != "*" {
+			expanded = append(expanded, c)
+			continue
+		}
+
+		grouped := map[string]bool{}
+		for _, t := range groupTags {
+			grouped[t] = true
+		}
+
+		keys := map[string]qb.KeyType{}
+		for _, p := range points {
+			for k := range p.Tags {
+				if !grouped[k] {
+					keys[k] = qb.TagType
+				}
+			}
+
+			for k := range p.Fields {
+				keys[k] = qb.FieldType
+			}
+		}
+
+		names := make([]string, 0, len(keys))
+		for k := range keys {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		for _, k := range names {
+			expanded = append(expanded, column{name: k, key: k, cast: keys[k]})
+		}
+	}
+
+	return expanded
+}
+
+type series struct {
+	key    string
+	tags   map[string]string
+	points []Point
+}
+
+// splitSeries splits points by their GROUP BY tags, series sorted by tags
+func splitSeries(points []Point, groupTags []string) []series {
+	index := map[string]int{}
+	var all []series
+
+	for _, p := range points {
+		values := make([]string, len(groupTags))
+		for i, t := range groupTags {
+			values[i] = t + "=" + p.Tags[t]
+		}
+		key := strings.Join(values, ",")
+
+		i, ok := index[key]
+		if !ok {
+			s := series{key: key}
+			if len(groupTags) > 0 {
+				s.tags = make(map[string]string, len(groupTags))
+				for _, t := range groupTags {
+					s.tags[t] = p.Tags[t]
+				}
+			}
+
+			i = len(all)
+			index[key] = i
+			all = append(all, s)
+		}
+
+		all[i].points = append(all[i].points, p)
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].key < all[j].key })
+
+	return all
+}
+
+// rawRows rows of raw fields, points without any selected field are skipped
+func rawRows(columns []column, points []Point) [][]interface{} {
+	var rows [][]interface{}
+
+	for _, p := range points {
+		row := []interface{}{formatTime(p.Time)}
+		hasField := false
+
+		for _, c := range columns {
+			var v interface{}
+
+			if f, ok := p.Fields[c.key]; ok && c.cast != qb.TagType {
+				v, hasField = f, true
+			} else if t, ok := p.Tags[c.key]; ok && c.cast != qb.FieldType {
+				v = t
+			}
+
+			row = append(row, encode(v))
+		}
+
+		if hasField {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// aggregateRows rows of aggregates, one per interval from the lower time bound
+// or the first point of all series, to the upper bound or the last point
+func aggregateRows(columns []column, points []Point, interval time.Duration, all []Point, start, end time.Time, fill interface{}) ([][]interface{}, error) {
+	if interval == 0 {
+		values, at, err := aggregateBucket(columns, points)
+		if err != nil {
+			return nil, err
+		}
+
+		t := time.Unix(0, 0).UTC()
+		if !start.IsZero() {
+			t = start
+		}
+
+		// A lone selector returns the time of the selected point
+		if len(columns) == 1 && selectors[columns[0].fn] {
+			t = at
+		}
+
+		return [][]interface{}{encodeRow(t, values)}, nil
+	}
+
+	if start.IsZero() {
+		start = all[0].Time
+	}
+
+	if end.IsZero() {
+		end = all[len(all)-1].Time
+	}
+
+	var buckets []bucket
+	i := 0
+
+	for t := alignTime(start, interval); !t.After(end); t = t.Add(interval) {
+		next := t.Add(interval)
+
+		for i < len(points) && points[i].Time.Before(t) {
+			i++
+		}
+
+		j := i
+		for j < len(points) && points[j].Time.Before(next) {
+			j++
+		}
+
+		values, _, err := aggregateBucket(columns, points[i:j])
+		if err != nil {
+			return nil, err
+		}
+
+		buckets = append(buckets, bucket{time: t, empty: i == j, values: values})
+		i = j
+	}
+
+	buckets, err := fillBuckets(buckets, fill)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]interface{}, 0, len(buckets))
+	for _, b := range buckets {
+		rows = append(rows, encodeRow(b.time, b.values))
+	}
+
+	return rows, nil
+}
+
+// alignTime start of the interval containing t, intervals start at the epoch
+func alignTime(t time.Time, interval time.Duration) time.Time {
+	ns := t.UnixNano()
+	offset := ns % int64(interval)
+	if offset < 0 {
+		offset += int64(interval)
+	}
+
+	return time.Unix(0, ns-offset).UTC()
+}
+
+// aggregateBucket aggregates of the columns and the time of the last selected
+// point
+func aggregateBucket(columns []column, points []Point) ([]interface{}, time.Time, error) {
+	values := make([]interface{}, len(columns))
+	at := time.Unix(0, 0).UTC()
+
+	for i, c := range columns {
+		if c.cast == qb.TagType {
+			return nil, at, fmt.Errorf("%s(%s::tag) is not supported", c.fn, c.key)
+		}
+
+		var selected []interface{}
+		var times []time.Time
+
+		for _, p := range points {
+			if v, ok := p.Fields[c.key]; ok {
+				selected = append(selected, v)
+				times = append(times, p.Time)
+			}
+		}
+
+		v, t, err := aggregateValues(c.fn, selected, times)
+		if err != nil {
+			return nil, at, err
+		}
+
+		values[i] = v
+		if !t.IsZero() {
+			at = t
+		}
+	}
+
+	return values, at, nil
+}
+
+// aggregateValues aggregate of values sorted by time, with the time of the
+// selected value for selectors
+func aggregateValues(fn string, values []interface{}, times []time.Time) (interface{}, time.Time, error) {
+	if fn == "count" {
+		return int64(len(values)), time.Time{}, nil
+	}
+
+	if len(values) == 0 {
+		return nil, time.Time{}, nil
+	}
+
+	switch fn {
+	case "first":
+		return values[0], times[0], nil
+	case "last":
+		return values[len(values)-1], times[len(times)-1], nil
+	}
+
+	floats := make([]float64, len(values))
+	integers := true
+
+	for i, v := range values {
+		switch n := v.(type) {
+		case int64:
+			floats[i] = float64(n)
+		case float64:
+			floats[i] = n
+			integers = false
+		default:
+			return nil, time.Time{}, fmt.Errorf("%s of %T values is not supported", fn, v)
+		}
+	}
+
+	switch fn {
+	case "mean":
+		sum := 0.0
+		for _, f := range floats {
+			sum += f
+		}
+
+		return sum / float64(len(floats)), time.Time{}, nil
+	case "sum":
+		if integers {
+			var sum int64
+			for _, v := range values {
+				sum += v.(int64)
+			}
+
+			return sum, time.Time{}, nil
+		}
+
+		sum := 0.0
+		for _, f := range floats {
+			sum += f
+		}
+
+		return sum, time.Time{}, nil
+	}
+
+	// min and max keep the first of equal values
+	selected := 0
+	for i, f := range floats {
+		if (fn == "min" && f < floats[selected]) || (fn == "max" && f > floats[selected]) {
+			selected = i
+		}
+	}
+
+	return values[selected], times[selected], nil
+}
+
+// fillBuckets applies FILL to the values of empty buckets
+func fillBuckets(buckets []bucket, fill interface{}) ([]bucket, error) {
+	switch fill {
+	case nil, "null":
+		return buckets, nil
+	case "none":
+		filled := buckets[:0]
+		for _, b := range buckets {
+			if !b.empty {
+				filled = append(filled, b)
+			}
+		}
+
+		return filled, nil
+	case "previous":
+		for i := 1; i < len(buckets); i++ {
+			for j, v := range buckets[i].values {
+				if v == nil {
+					buckets[i].values[j] = buckets[i-1].values[j]
+				}
+			}
+		}
+
+		return buckets, nil
+	case "linear":
+		for j := range buckets[0].values {
+			fillLinear(buckets, j)
+		}
+
+		return buckets, nil
+	}
+
+	value, err := normalize(fill)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported fill(%v)", fill)
+	}
+
+	for _, b := range buckets {
+		for j, v := range b.values {
+			if v == nil {
+				b.values[j] = value
+			}
+		}
+	}
+
+	return buckets, nil
+}
+
+// fillLinear interpolates nil values of a column between its neighbours,
+// integers stay integers
+func fillLinear(buckets []bucket, j int) {
+	prev := -1
+
+	for i, b := range buckets {
+		if b.values[j] == nil {
+			continue
+		}
+
+		if prev >= 0 && i-prev > 1 {
+			from, ok1 := toFloat(buckets[prev].values[j])
+			to, ok2 := toFloat(b.values[j])
+			_, integers := b.values[j].(int64)
+			_, prevInteger := buckets[prev].values[j].(int64)
+
+			for k := prev + 1; ok1 && ok2 && k < i; k++ {
+				v := from + (to-from)*float64(k-prev)/float64(i-prev)
+
+				if integers && prevInteger {
+					buckets[k].values[j] = int64(v)
+				} else {
+					buckets[k].values[j] = v
+				}
+			}
+		}
+
+		prev = i
+	}
+}
+
+// paginate applies ORDER BY time DESC, OFFSET and LIMIT to the rows of a series
+func paginate(rows [][]interface{}, spec qb.QuerySpec) [][]interface{} {
+	if strings.EqualFold(spec.Order, "DESC") {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	if spec.Offset != nil {
+		if int(*spec.Offset) >= len(rows) {
+			return nil
+		}
+
+		rows = rows[*spec.Offset:]
+	}
+
+	if spec.Limit != nil && int(*spec.Limit) < len(rows) {
+		rows = rows[:*spec.Limit]
+	}
+
+	return rows
+}
+
+func encodeRow(t time.Time, values []interface{}) []interface{} {
+	row := make([]interface{}, 0, len(values)+1)
+	row = append(row, formatTime(t))
+
+	for _, v := range values {
+		row = append(row, encode(v))
+	}
+
+	return row
+}
+
+// encode numbers as json.Number, as the Client decodes them
+func encode(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int64:
+		return json.Number(strconv.FormatInt(n, 10))
+	case float64:
+		b, err := json.Marshal(n)
+		if err != nil {
+			return nil
+		}
+
+		return json.Number(b)
+	}
+
+	return v
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
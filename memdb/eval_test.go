@@ -0,0 +1,114 @@
+package memdb
+
+import (
+	"testing"
+)
+
+func TestRawFields(t *testing.T) {
+	db := newTestDB(t)
+
+	assert(t, execute(t, db, `SELECT "value", "host" FROM "cpu" WHERE "host" = 'a'`),
+		`[{"name":"cpu","columns":["time","value","host"],"values":[["2020-01-01T00:00:00Z",1,"a"],["2020-01-01T00:02:00Z",3,"a"]]}]`)
+
+	assert(t, execute(t, db, `SELECT * FROM "mem"`),
+		`[{"name":"mem","columns":["time","free","host","ok","state"],"values":[["2020-01-01T00:00:00Z",10,"a",true,"up"]]}]`)
+
+	assert(t, execute(t, db, `SELECT "value" AS v FROM "cpu" GROUP BY "host" ORDER BY time DESC LIMIT 1`),
+		`[{"name":"cpu","tags":{"host":"a"},"columns":["time","v"],"values":[["2020-01-01T00:02:00Z",3]]},`+
+			`{"name":"cpu","tags":{"host":"b"},"columns":["time","v"],"values":[["2020-01-01T00:05:00Z",4]]}]`)
+
+	assert(t, execute(t, db, `SELECT "value" FROM "cpu" LIMIT 2 OFFSET 4`), `null`)
+	assert(t, execute(t, db, `SELECT "missing" FROM "cpu"`), `null`)
+	assert(t, execute(t, db, `SELECT "value" FROM "disk"`), `null`)
+}
+
+func TestAggregates(t *testing.T) {
+	db := newTestDB(t)
+
+	assert(t, execute(t, db, `SELECT MEAN("value"), SUM("count"), COUNT("value"), min("value"), max("count") FROM "cpu"`),
+		`[{"name":"cpu","columns":["time","mean","sum","count","min","max"],"values":[["1970-01-01T00:00:00Z",2.5,10,4,1,4]]}]`)
+
+	assert(t, execute(t, db, `SELECT SUM("value") AS total, first("value") FROM "cpu" WHERE time >= '2020-01-01T00:00:00Z' GROUP BY *`),
+		`[{"name":"cpu","tags":{"host":"a","region":"eu"},"columns":["time","total","first"],"values":[["2020-01-01T00:00:00Z",4,1]]},`+
+			`{"name":"cpu","tags":{"host":"b","region":"us"},"columns":["time","total","first"],"values":[["2020-01-01T00:00:00Z",6,2]]}]`)
+
+	// A lone selector keeps the time of its point
+	assert(t, execute(t, db, `SELECT last("value") FROM "cpu"`),
+		`[{"name":"cpu","columns":["time","last"],"values":[["2020-01-01T00:05:00Z",4]]}]`)
+
+	assert(t, execute(t, db, `SELECT MEAN("value"), MEAN("count") FROM "cpu"`),
+		`[{"name":"cpu","columns":["time","mean","mean_1"],"values":[["1970-01-01T00:00:00Z",2.5,2.5]]}]`)
+}
+
+func TestGroupByTime(t *testing.T) {
+	db := newTestDB(t)
+
+	query := `SELECT MEAN("value") FROM "cpu" WHERE time >= '2020-01-01T00:00:00Z' AND time < '2020-01-01T00:06:00Z' GROUP BY time(2m)`
+
+	assert(t, execute(t, db, query),
+		`[{"name":"cpu","columns":["time","mean"],"values":[["2020-01-01T00:00:00Z",1.5],["2020-01-01T00:02:00Z",3],["2020-01-01T00:04:00Z",4]]}]`)
+
+	assert(t, execute(t, db, `SELECT COUNT("value") FROM "cpu" WHERE "host" = 'b' GROUP BY time(1m)`),
+		`[{"name":"cpu","columns":["time","count"],"values":[["2020-01-01T00:01:00Z",1],["2020-01-01T00:02:00Z",0],`+
+			`["2020-01-01T00:03:00Z",0],["2020-01-01T00:04:00Z",0],["2020-01-01T00:05:00Z",1]]}]`)
+
+	query = `SELECT MEAN("value") FROM "cpu" WHERE "host" = 'b' GROUP BY time(1m) `
+
+	for fill, expected := range map[string]string{
+		"":               `[["2020-01-01T00:01:00Z",2],["2020-01-01T00:02:00Z",null],["2020-01-01T00:03:00Z",null],["2020-01-01T00:04:00Z",null],["2020-01-01T00:05:00Z",4]]`,
+		"fill(none)":     `[["2020-01-01T00:01:00Z",2],["2020-01-01T00:05:00Z",4]]`,
+		"fill(previous)": `[["2020-01-01T00:01:00Z",2],["2020-01-01T00:02:00Z",2],["2020-01-01T00:03:00Z",2],["2020-01-01T00:04:00Z",2],["2020-01-01T00:05:00Z",4]]`,
+		"fill(linear)":   `[["2020-01-01T00:01:00Z",2],["2020-01-01T00:02:00Z",2.5],["2020-01-01T00:03:00Z",3],["2020-01-01T00:04:00Z",3.5],["2020-01-01T00:05:00Z",4]]`,
+		"fill(0)":        `[["2020-01-01T00:01:00Z",2],["2020-01-01T00:02:00Z",0],["2020-01-01T00:03:00Z",0],["2020-01-01T00:04:00Z",0],["2020-01-01T00:05:00Z",4]]`,
+	} {
+		assert(t, execute(t, db, query+fill), `[{"name":"cpu","columns":["time","mean"],"values":`+expected+`}]`)
+	}
+
+	assert(t, execute(t, db, `SELECT SUM("count") FROM "cpu" GROUP BY time(5m), "host" fill(none) ORDER BY time DESC`),
+		`[{"name":"cpu","tags":{"host":"a"},"columns":["time","sum"],"values":[["2020-01-01T00:00:00Z",4]]},`+
+			`{"name":"cpu","tags":{"host":"b"},"columns":["time","sum"],"values":[["2020-01-01T00:05:00Z",4],["2020-01-01T00:00:00Z",2]]}]`)
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	db := newTestDB(t)
+
+	for _, query := range []string{
+		`SELECT MEAN("value"), "host" FROM "cpu"`,
+		`SELECT "value" FROM "cpu" GROUP BY time(1m)`,
+		`SELECT MEDIAN("value") FROM "cpu"`,
+		`SELECT MEAN("state") FROM "mem"`,
+		`SELECT "value" INTO "copy" FROM "cpu"`,
+	} {
+		resp, err := db.Execute(ctx(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Err != "" || len(resp.Results) != 1 || resp.Results[0].Err == "" {
+			t.Errorf("Expected a statement error for %s but got %+v", query, resp)
+		}
+	}
+}
+
+func TestParseInterval(t *testing.T) {
+	for literal, expected := range map[string]string{
+		"":     "0s",
+		"10s":  "10s",
+		"100u": "100µs",
+		"1d":   "24h0m0s",
+		"2w":   "336h0m0s",
+	} {
+		d, err := parseInterval(literal)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert(t, d.String(), expected)
+	}
+
+	for _, literal := range []string{"0s", "$__interval", "1y"} {
+		if _, err := parseInterval(literal); err == nil {
+			t.Errorf("Expected an error for %s", literal)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Package memdb provides an in-memory store evaluating the subset of InfluxQL
+// the query builder emits, so tests can assert on query results without an
+// InfluxDB server:
+//
+//	db := memdb.New()
+//	db.Write(memdb.Point{Measurement: "cpu", Tags: map[string]string{"host": "a"}, Fields: map[string]interface{}{"value": 1.5}, Time: t})
+//	resp, err := db.Execute(ctx, qb.New().Select(`MEAN("value")`).From("cpu").Build())
+//
+// Supported are field and tag selection, criteria on time, tags and fields,
+// MEAN, SUM, COUNT, MIN, MAX, FIRST and LAST, GROUP BY time and tags, FILL,
+// ORDER BY time, LIMIT and OFFSET. Responses look like the ones decoded by
+// qb.Client: numbers are json.Number and times RFC3339 strings. Databases and
+// retention policies are ignored.
+package memdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+// Point Point of a measurement
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]interface{}
+	Time        time.Time
+}
+
+// DB In-memory store, safe for concurrent use
+type DB struct {
+	mu     sync.RWMutex
+	points map[string][]Point
+}
+
+// New New empty DB
+func New() *DB {
+	return &DB{points: map[string][]Point{}}
+}
+
+// Write Store points. Field values must be integers, floats, strings or bools
+func (db *DB) Write(points ...Point) error {
+	normalized := make([]Point, len(points))
+
+	for i, p := range points {
+		if p.Measurement == "" {
+			return fmt.Errorf("memdb: point without measurement")
+		}
+
+		if len(p.Fields) == 0 {
+			return fmt.Errorf("memdb: point of %s without fields", p.Measurement)
+		}
+
+		fields := make(map[string]interface{}, len(p.Fields))
+		for k, v := range p.Fields {
+			n, err := normalize(v)
+			if err != nil {
+				return fmt.Errorf("memdb: field %s of %s: %w", k, p.Measurement, err)
+			}
+			fields[k] = n
+		}
+
+		tags := make(map[string]string, len(p.Tags))
+		for k, v := range p.Tags {
+			tags[k] = v
+		}
+
+		normalized[i] = Point{Measurement: p.Measurement, Tags: tags, Fields: fields, Time: p.Time.UTC()}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for _, p := range normalized {
+		db.points[p.Measurement] = append(db.points[p.Measurement], p)
+	}
+
+	return nil
+}
+
+// Measurements Names of the stored measurements, sorted
+func (db *DB) Measurements() []string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	names := make([]string, 0, len(db.points))
+	for name := range db.points {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Execute Evaluate ";" separated SELECT statements, implementing qb.Executor.
+// Parse errors fail the whole request, evaluation errors their statement
+func (db *DB) Execute(ctx context.Context, query string) (*qb.Response, error) {
+	statements := splitStatements(query)
+	resp := &qb.Response{Results: make([]qb.Result, 0, len(statements))}
+
+	builders := make([]qb.QueryBuilder, len(statements))
+	for i, s := range statements {
+		q, err := qb.Parse(s)
+		if err != nil {
+			return &qb.Response{Err: err.Error()}, nil
+		}
+
+		builders[i] = q
+	}
+
+	for i, q := range builders {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result, err := db.evaluate(q.ToSpec())
+		if err != nil {
+			result = qb.Result{Err: err.Error()}
+		}
+
+		result.StatementID = i
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, nil
+}
+
+// snapshot points of a measurement, sorted by time
+func (db *DB) snapshot(measurement string) []Point {
+	db.mu.RLock()
+	points := append([]Point(nil), db.points[measurement]...)
+	db.mu.RUnlock()
+
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+
+	return points
+}
+
+// splitStatements splits a query on ";" outside of quotes
+func splitStatements(query string) []string {
+	var statements []string
+	var quote byte
+	start := 0
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ';':
+			statements = append(statements, query[start:i])
+			start = i + 1
+		}
+	}
+
+	statements = append(statements, query[start:])
+
+	nonEmpty := statements[:0]
+	for _, s := range statements {
+		if strings.TrimSpace(s) != "" {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+
+	return nonEmpty
+}
+
+// normalize converts a field value to int64, float64, string or bool
+func normalize(v interface{}) (interface{}, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint:
+		return int64(n), nil
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		return int64(n), nil
+	case float32:
+		return float64(n), nil
+	case float64, string, bool:
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %T", v)
+}
@@ -0,0 +1,153 @@
+package memdb
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+var t0 = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestDB(t *testing.T) *DB {
+	db := New()
+
+	points := []Point{
+		{Measurement: "cpu", Tags: map[string]string{"host": "a", "region": "eu"}, Fields: map[string]interface{}{"value": 1.0, "count": 1}, Time: t0},
+		{Measurement: "cpu", Tags: map[string]string{"host": "b", "region": "us"}, Fields: map[string]interface{}{"value": 2.0, "count": 2}, Time: t0.Add(time.Minute)},
+		{Measurement: "cpu", Tags: map[string]string{"host": "a", "region": "eu"}, Fields: map[string]interface{}{"value": 3.0, "count": 3}, Time: t0.Add(2 * time.Minute)},
+		{Measurement: "cpu", Tags: map[string]string{"host": "b", "region": "us"}, Fields: map[string]interface{}{"value": 4.0, "count": 4}, Time: t0.Add(5 * time.Minute)},
+		{Measurement: "mem", Tags: map[string]string{"host": "a"}, Fields: map[string]interface{}{"free": int64(10), "ok": true, "state": "up"}, Time: t0},
+	}
+
+	if err := db.Write(points...); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// execute runs a single statement and returns its series as JSON
+func execute(t *testing.T, db *DB, query string) string {
+	t.Helper()
+
+	resp, err := db.Execute(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := resp.Error(); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+
+	if err := resp.Results[0].Error(); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+
+	return toJSON(t, resp.Results[0].Series)
+}
+
+func toJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func ctx() context.Context {
+	return context.Background()
+}
+
+func assert(t *testing.T, got interface{}, expected interface{}) {
+	t.Helper()
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected\n%v\nbut got\n%v", expected, got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	db := newTestDB(t)
+
+	assert(t, db.Measurements(), []string{"cpu", "mem"})
+
+	for _, p := range []Point{
+		{Fields: map[string]interface{}{"value": 1}},
+		{Measurement: "cpu"},
+		{Measurement: "cpu", Fields: map[string]interface{}{"value": []int{1}}},
+	} {
+		if err := db.Write(p); err == nil {
+			t.Errorf("Expected an error writing %+v", p)
+		}
+	}
+
+	// Failed writes store nothing
+	if err := db.Write(Point{Measurement: "disk", Fields: map[string]interface{}{"v": 1}}, Point{Measurement: "disk"}); err == nil {
+		t.Error("Expected an error")
+	}
+	assert(t, db.Measurements(), []string{"cpu", "mem"})
+}
+
+func TestExecute(t *testing.T) {
+	db := newTestDB(t)
+
+	resp, err := db.Execute(context.Background(), `SELECT "value" FROM "cpu" LIMIT 1; SELECT "free" FROM "mem"; SELECT max(x) FROM "cpu" GROUP BY time($__interval)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, len(resp.Results), 3)
+	assert(t, resp.Results[1].StatementID, 1)
+	assert(t, resp.Results[1].Series[0].Values, [][]interface{}{{"2020-01-01T00:00:00Z", json.Number("10")}})
+	assert(t, resp.Results[2].StatementID, 2)
+	assert(t, resp.Results[2].Err != "", true)
+
+	resp, err = db.Execute(context.Background(), `SELECT FROM`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, resp.Err != "", true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := db.Execute(ctx, `SELECT "value" FROM "cpu"`); err != context.Canceled {
+		t.Errorf("Expected context.Canceled but got %v", err)
+	}
+}
+
+func TestExecuteBuilder(t *testing.T) {
+	db := newTestDB(t)
+
+	var executor qb.Executor = db
+
+	query := qb.New().
+		Select(`MEAN("value") AS m`).
+		From("cpu").
+		WhereEq("host", "a").
+		And("time", ">=", "2020-01-01T00:00:00Z").
+		Build()
+
+	resp, err := executor.Execute(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, resp.Results[0].Series[0].Columns, []string{"time", "m"})
+	assert(t, resp.Results[0].Series[0].Values, [][]interface{}{{"2020-01-01T00:00:00Z", json.Number("2")}})
+}
+
+func TestSplitStatements(t *testing.T) {
+	assert(t, splitStatements(`SELECT a FROM b; SELECT "c;" FROM d WHERE e = ';'\n;`), []string{
+		`SELECT a FROM b`,
+		` SELECT "c;" FROM d WHERE e = ';'\n`,
+	})
+	assert(t, len(splitStatements(" ; ")), 0)
+}
@@ -0,0 +1,331 @@
+package influxquerybuilder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ErrMsgpack Malformed application/x-msgpack response
+var ErrMsgpack = errors.New("influxquerybuilder: malformed msgpack response")
+
+// Extension types of times, msgp's time and the msgpack timestamp
+const (
+	msgpTimeExt      = 5
+	msgpackTimestamp = -1
+)
+
+// decodeMsgpack decodes an application/x-msgpack response into the model of
+// the JSON path: numbers become json.Number and times RFC3339 strings
+func decodeMsgpack(r io.Reader) (*Response, error) {
+	d := &msgpackDecoder{r: bufio.NewReader(r)}
+
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+
+	body, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("response is %T: %w", v, ErrMsgpack)
+	}
+
+	resp := &Response{}
+	resp.Err, _ = body["error"].(string)
+
+	results, _ := body["results"].([]interface{})
+	for _, r := range results {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("result is %T: %w", r, ErrMsgpack)
+		}
+
+		result, err := msgpackResult(m)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, nil
+}
+
+func msgpackResult(m map[string]interface{}) (Result, error) {
+	result := Result{}
+
+	if id, ok := m["statement_id"].(json.Number); ok {
+		n, err := id.Int64()
+		if err != nil {
+			return result, fmt.Errorf("statement_id %s: %w", id, ErrMsgpack)
+		}
+		result.StatementID = int(n)
+	}
+
+	result.Err, _ = m["error"].(string)
+	result.Partial, _ = m["partial"].(bool)
+
+	messages, _ := m["messages"].([]interface{})
+	for _, msg := range messages {
+		if mm, ok := msg.(map[string]interface{}); ok {
+			level, _ := mm["level"].(string)
+			text, _ := mm["text"].(string)
+			result.Messages = append(result.Messages, Message{Level: level, Text: text})
+		}
+	}
+
+	series, _ := m["series"].([]interface{})
+	for _, s := range series {
+		sm, ok := s.(map[string]interface{})
+		if !ok {
+			return result, fmt.Errorf("series is %T: %w", s, ErrMsgpack)
+		}
+
+		row := Series{}
+		row.Name, _ = sm["name"].(string)
+		row.Partial, _ = sm["partial"].(bool)
+
+		if tags, ok := sm["tags"].(map[string]interface{}); ok && len(tags) > 0 {
+			row.Tags = make(map[string]string, len(tags))
+			for k, v := range tags {
+				row.Tags[k], _ = v.(string)
+			}
+		}
+
+		columns, _ := sm["columns"].([]interface{})
+		for _, c := range columns {
+			name, _ := c.(string)
+			row.Columns = append(row.Columns, name)
+		}
+
+		values, _ := sm["values"].([]interface{})
+		for _, v := range values {
+			vs, ok := v.([]interface{})
+			if !ok {
+				return result, fmt.Errorf("values are %T: %w", v, ErrMsgpack)
+			}
+			row.Values = append(row.Values, vs)
+		}
+
+		result.Series = append(result.Series, row)
+	}
+
+	return result, nil
+}
+
+type msgpackDecoder struct {
+	r *bufio.Reader
+}
+
+func (d *msgpackDecoder) value() (interface{}, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return nil, d.eof(err)
+	}
+
+	switch {
+	case b <= 0x7f:
+		return json.Number(strconv.Itoa(int(b))), nil
+	case b >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(b)))), nil
+	case b&0xf0 == 0x80:
+		return d.mapOf(int(b & 0x0f))
+	case b&0xf0 == 0x90:
+		return d.arrayOf(int(b & 0x0f))
+	case b&0xe0 == 0xa0:
+		return d.str(int(b & 0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9:
+		return d.strN(1)
+	case 0xc5, 0xda:
+		return d.strN(2)
+	case 0xc6, 0xdb:
+		return d.strN(4)
+	case 0xc7:
+		return d.extN(1)
+	case 0xc8:
+		return d.extN(2)
+	case 0xc9:
+		return d.extN(4)
+	case 0xca:
+		n, err := d.uint(4)
+		return json.Number(strconv.FormatFloat(float64(math.Float32frombits(uint32(n))), 'f', -1, 32)), err
+	case 0xcb:
+		n, err := d.uint(8)
+		return json.Number(strconv.FormatFloat(math.Float64frombits(n), 'f', -1, 64)), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := d.uint(1 << (b - 0xcc))
+		return json.Number(strconv.FormatUint(n, 10)), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		n, err := d.uint(size)
+		shift := uint(64 - 8*size)
+		return json.Number(strconv.FormatInt(int64(n<<shift)>>shift, 10)), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (b - 0xd4))
+	case 0xdc:
+		n, err := d.uint(2)
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(int(n))
+	case 0xdd:
+		n, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(int(n))
+	case 0xde:
+		n, err := d.uint(2)
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(int(n))
+	case 0xdf:
+		n, err := d.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(int(n))
+	}
+
+	return nil, fmt.Errorf("format 0x%x: %w", b, ErrMsgpack)
+}
+
+func (d *msgpackDecoder) eof(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// uint reads a big endian unsigned integer of size bytes
+func (d *msgpackDecoder) uint(size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(d.r, buf[8-size:]); err != nil {
+		return 0, d.eof(err)
+	}
+
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// bytes reads n bytes, growing the buffer only as data arrives
+func (d *msgpackDecoder) bytes(n int) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, d.r, int64(n)); err != nil {
+		return nil, d.eof(err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (d *msgpackDecoder) str(n int) (interface{}, error) {
+	b, err := d.bytes(n)
+	return string(b), err
+}
+
+func (d *msgpackDecoder) strN(size int) (interface{}, error) {
+	n, err := d.uint(size)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.str(int(n))
+}
+
+func (d *msgpackDecoder) extN(size int) (interface{}, error) {
+	n, err := d.uint(size)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.ext(int(n))
+}
+
+// ext decodes time extensions, other extensions are malformed responses
+func (d *msgpackDecoder) ext(n int) (interface{}, error) {
+	typ, err := d.r.ReadByte()
+	if err != nil {
+		return nil, d.eof(err)
+	}
+
+	data, err := d.bytes(n)
+	if err != nil {
+		return nil, err
+	}
+
+	var t time.Time
+
+	switch {
+	case int8(typ) == msgpTimeExt && n == 12:
+		sec := int64(binary.BigEndian.Uint64(data[:8]))
+		nsec := int64(binary.BigEndian.Uint32(data[8:]))
+		t = time.Unix(sec, nsec)
+	case int8(typ) == msgpackTimestamp && n == 4:
+		t = time.Unix(int64(binary.BigEndian.Uint32(data)), 0)
+	case int8(typ) == msgpackTimestamp && n == 8:
+		v := binary.BigEndian.Uint64(data)
+		t = time.Unix(int64(v&0x3ffffffff), int64(v>>34))
+	case int8(typ) == msgpackTimestamp && n == 12:
+		nsec := int64(binary.BigEndian.Uint32(data[:4]))
+		sec := int64(binary.BigEndian.Uint64(data[4:]))
+		t = time.Unix(sec, nsec)
+	default:
+		return nil, fmt.Errorf("extension %d: %w", int8(typ), ErrMsgpack)
+	}
+
+	return formatTime(t), nil
+}
+
+func (d *msgpackDecoder) arrayOf(n int) (interface{}, error) {
+	values := make([]interface{}, 0, min(n, 1024))
+
+	for i := 0; i < n; i++ {
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+func (d *msgpackDecoder) mapOf(n int) (interface{}, error) {
+	values := make(map[string]interface{}, min(n, 1024))
+
+	for i := 0; i < n; i++ {
+		k, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key %T: %w", k, ErrMsgpack)
+		}
+
+		if values[key], err = d.value(); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
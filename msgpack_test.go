@@ -0,0 +1,57 @@
+package influxquerybuilder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+func decodeMsgpackValue(data []byte) (interface{}, error) {
+	d := &msgpackDecoder{r: bufio.NewReader(bytes.NewReader(data))}
+	return d.value()
+}
+
+func TestMsgpackValues(t *testing.T) {
+	for _, c := range []struct {
+		data     []byte
+		expected interface{}
+	}{
+		{[]byte{0x05}, json.Number("5")},
+		{[]byte{0xff}, json.Number("-1")},
+		{[]byte{0xcd, 0x01, 0x00}, json.Number("256")},
+		{[]byte{0xd2, 0xff, 0xff, 0xff, 0xfe}, json.Number("-2")},
+		{[]byte{0xca, 0x3f, 0xc0, 0x00, 0x00}, json.Number("1.5")},
+		{[]byte{0xa2, 'o', 'k'}, "ok"},
+		{[]byte{0xc0}, nil},
+		{[]byte{0xc3}, true},
+		// msgpack timestamp 32
+		{[]byte{0xd6, 0xff, 0x5e, 0x0b, 0xe1, 0x00}, "2020-01-01T00:00:00Z"},
+	} {
+		v, err := decodeMsgpackValue(c.data)
+		assert(t, err, nil)
+		assert(t, v, c.expected)
+	}
+}
+
+func TestMsgpackErrors(t *testing.T) {
+	_, err := decodeMsgpackValue([]byte{0xc1})
+	assert(t, errors.Is(err, ErrMsgpack), true)
+
+	_, err = decodeMsgpackValue([]byte{0xd4, 0x01, 0x00})
+	assert(t, errors.Is(err, ErrMsgpack), true)
+
+	_, err = decodeMsgpackValue([]byte{0xa5, 'o'})
+	assert(t, err, io.ErrUnexpectedEOF)
+
+	_, err = decodeMsgpackValue([]byte{0x81, 0x01, 0x01})
+	assert(t, errors.Is(err, ErrMsgpack), true)
+
+	_, err = decodeMsgpack(bytes.NewReader([]byte{0x91, 0x01}))
+	assert(t, errors.Is(err, ErrMsgpack), true)
+
+	_, err = decodeMsgpack(bytes.NewReader(nil))
+	assert(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,76 @@
+package influxquerybuilder
+
+// Operator Comparison operator
+type Operator string
+
+// Operators
+const (
+	Eq       Operator = "="
+	Neq      Operator = "!="
+	Lt       Operator = "<"
+	Lte      Operator = "<="
+	Gt       Operator = ">"
+	Gte      Operator = ">="
+	Match    Operator = "=~"
+	NotMatch Operator = "!~"
+)
+
+// Valid Whether the operator is part of the InfluxQL grammar, "<>" is
+// accepted as an alias of "!="
+func (o Operator) Valid() bool {
+	switch o {
+	case Eq, Neq, Lt, Lte, Gt, Gte, Match, NotMatch, "<>":
+		return true
+	}
+
+	return false
+}
+
+// IsRegex Whether the operator compares against a regex
+func (o Operator) IsRegex() bool {
+	return o == Match || o == NotMatch
+}
+
+// WhereEq WHERE "key" = value
+func (q *Query) WhereEq(key string, value interface{}) QueryBuilder {
+	return q.Where(key, string(Eq), value)
+}
+
+// AndEq AND "key" = value
+func (q *Query) AndEq(key string, value interface{}) QueryBuilder {
+	return q.And(key, string(Eq), value)
+}
+
+// OrEq OR "key" = value
+func (q *Query) OrEq(key string, value interface{}) QueryBuilder {
+	return q.Or(key, string(Eq), value)
+}
+
+// WhereIn WHERE ("key" = v1 OR "key" = v2 ...)
+func (q *Query) WhereIn(key string, values ...interface{}) QueryBuilder {
+	return q.WhereBrackets(inBrackets(key, values))
+}
+
+// AndIn AND ("key" = v1 OR "key" = v2 ...)
+func (q *Query) AndIn(key string, values ...interface{}) QueryBuilder {
+	return q.AndBrackets(inBrackets(key, values))
+}
+
+// OrIn OR ("key" = v1 OR "key" = v2 ...)
+func (q *Query) OrIn(key string, values ...interface{}) QueryBuilder {
+	return q.OrBrackets(inBrackets(key, values))
+}
+
+func inBrackets(key string, values []interface{}) QueryBuilder {
+	builder := New()
+
+	for i, v := range values {
+		if i == 0 {
+			builder.WhereEq(key, v)
+		} else {
+			builder.OrEq(key, v)
+		}
+	}
+
+	return builder
+}
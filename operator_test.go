@@ -0,0 +1,80 @@
+package influxquerybuilder
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOperatorValid(t *testing.T) {
+	for _, op := range []Operator{Eq, Neq, Lt, Lte, Gt, Gte, Match, NotMatch, "<>"} {
+		assert(t, op.Valid(), true)
+	}
+
+	for _, op := range []Operator{"==", "=>", "=<", "", "IN"} {
+		assert(t, op.Valid(), false)
+	}
+}
+
+func TestWhereEq(t *testing.T) {
+	expected := `SELECT "temperature" FROM "measurement" WHERE "host" = 'a' AND "region" = 'eu' OR "region" = 'us'`
+	q := New().
+		Select("temperature").
+		From("measurement").
+		WhereEq("host", "a").
+		AndEq("region", "eu").
+		OrEq("region", "us").
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestWhereIn(t *testing.T) {
+	expected := `SELECT "temperature" FROM "measurement" WHERE ("host" = 'a' OR "host" = 'b') AND ("cpu" = 0 OR "cpu" = 1) OR ("region" = 'eu')`
+	builder := New()
+	q := builder.
+		Select("temperature").
+		From("measurement").
+		WhereIn("host", "a", "b").
+		AndIn("cpu", 0, 1).
+		OrIn("region", "eu").
+		Build()
+
+	assert(t, q, expected)
+	assert(t, builder.Validate(), nil)
+}
+
+func TestWhereInValidate(t *testing.T) {
+	err := New().
+		Select("temperature").
+		From("measurement").
+		WhereEq("host", "a").
+		AndIn("region").
+		Validate()
+	assert(t, err, ErrEmptyBrackets)
+
+	// Empty brackets are left out of the query
+	q := New().Select("temperature").From("measurement").WhereIn("host")
+	assert(t, q.Build(), `SELECT "temperature" FROM "measurement"`)
+	assert(t, q.BuildPretty(PrettyOptions{}), `SELECT "temperature"`+"\n"+`FROM "measurement"`)
+
+	q = New().Select("temperature").From("measurement").WhereEq("host", "a").AndIn("region").AndBrackets(New().WhereIn("dc"))
+	assert(t, q.Build(), `SELECT "temperature" FROM "measurement" WHERE "host" = 'a'`)
+	assert(t, q.BuildPretty(PrettyOptions{}), `SELECT "temperature"`+"\n"+`FROM "measurement"`+"\n"+`WHERE "host" = 'a'`)
+}
+
+func TestInvalidOperator(t *testing.T) {
+	err := New().
+		Select("temperature").
+		From("measurement").
+		Where("temperature", "=>", 10).
+		Validate()
+	assert(t, errors.Is(err, ErrInvalidOperator), true)
+
+	err = New().
+		Select("temperature").
+		From("measurement").
+		Where("temperature", string(Gte), 10).
+		Or("humidity", "==", 1).
+		Validate()
+	assert(t, errors.Is(err, ErrInvalidOperator), true)
+}
@@ -0,0 +1,262 @@
+package influxquerybuilder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+)
+
+// ErrPageCursor A keyset page whose last row has no usable time
+var ErrPageCursor = errors.New("influxquerybuilder: keyset pagination needs a time column in every row")
+
+// ErrPageLimit A page beyond the LIMIT of the query
+var ErrPageLimit = errors.New("influxquerybuilder: page beyond the LIMIT of the query")
+
+// Page A page of results
+type Page struct {
+	Number int
+	Query  string
+	Result Result
+}
+
+// Rows Number of rows of the page across all series
+func (p Page) Rows() int {
+	rows := 0
+	for _, s := range p.Result.Series {
+		rows += len(s.Values)
+	}
+
+	return rows
+}
+
+// Cursor Position after a keyset page: the time of its last row and the
+// number of rows at that time, which the next page skips. Points of several
+// series often share a time, so paging on time alone would drop them
+type Cursor struct {
+	Time interface{}
+	Ties uint
+}
+
+// Paginator Paginator over the results of a query, by keyset on time unless
+// UseOffset is set
+type Paginator struct {
+	query    *Query
+	err      error
+	executor Executor
+	size     uint
+	offset   bool
+}
+
+// Paginate New Paginator yielding pages of size rows
+func Paginate(builder QueryBuilder, executor Executor, size uint) *Paginator {
+	if size == 0 {
+		size = 1
+	}
+
+	p := &Paginator{executor: executor, size: size}
+
+	q, ok := builder.(*Query)
+	if !ok {
+		q = &Query{}
+		if _, err := q.FromSpec(builder.ToSpec()); err != nil {
+			p.err = fmt.Errorf("pagination: %w", err)
+		}
+	}
+	p.query = q
+
+	return p
+}
+
+// UseOffset Page with LIMIT size OFFSET n*size instead of time > last seen,
+// for queries keyset pagination cannot page such as GROUP BY tags. An OFFSET
+// of the query is added to the one of every page
+func (p *Paginator) UseOffset() *Paginator {
+	p.offset = true
+	return p
+}
+
+// Query Query of the page after the given cursor, nil for the first page.
+// Offset mode only uses the page number. The OFFSET of the query only applies
+// to the first keyset page, later ones skip the rows of the cursor instead.
+// A LIMIT of the query caps the rows of all pages together
+func (p *Paginator) Query(number int, cursor *Cursor) (QueryBuilder, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	size := p.pageSize(number)
+	if size == 0 {
+		return nil, fmt.Errorf("page %d: %w", number, ErrPageLimit)
+	}
+
+	c := *p.query
+
+	if p.offset {
+		c.Offset(p.query.offset + uint(number)*p.size)
+	} else if cursor != nil {
+		// Keep the cursor out of top level OR criteria
+		c.bracketOr()
+
+		op := Gte
+		if c.order == "DESC" {
+			op = Lte
+		}
+
+		c.and = append([]Tag(nil), c.and...)
+		c.addCriteria("time", op, cursor.Time)
+		c.offset, c._offset = cursor.Ties, cursor.Ties > 0
+	}
+
+	return c.Limit(size), nil
+}
+
+// pageSize rows of a page, less than size for the last page within the LIMIT
+// of the query and 0 beyond it
+func (p *Paginator) pageSize(number int) uint {
+	if !p.query._limit {
+		return p.size
+	}
+
+	fetched := uint(number) * p.size
+	if fetched >= p.query.limit {
+		return 0
+	}
+
+	return min(p.size, p.query.limit-fetched)
+}
+
+// Pages Iterate over the pages until one has fewer than size rows. Iteration
+// stops after the first error, which is yielded with an empty page
+func (p *Paginator) Pages(ctx context.Context) iter.Seq2[Page, error] {
+	return func(yield func(Page, error) bool) {
+		if p.err != nil {
+			yield(Page{}, p.err)
+			return
+		}
+
+		if !p.offset && len(p.query.groupByTags) > 0 {
+			yield(Page{}, unsupported("keyset pagination with GROUP BY tags"))
+			return
+		}
+
+		var cursor *Cursor
+
+		for number := 0; p.pageSize(number) > 0; number++ {
+			if err := ctx.Err(); err != nil {
+				yield(Page{}, err)
+				return
+			}
+
+			query, err := p.Query(number, cursor)
+			if err != nil {
+				yield(Page{}, err)
+				return
+			}
+
+			page := Page{Number: number, Query: query.Build()}
+
+			result, err := p.execute(ctx, page.Query)
+			if err != nil {
+				yield(Page{}, err)
+				return
+			}
+
+			page.Result = result
+			rows := page.Rows()
+
+			if rows == 0 {
+				return
+			}
+
+			if !p.offset {
+				if cursor, err = nextCursor(result, cursor); err != nil {
+					yield(Page{}, err)
+					return
+				}
+			}
+
+			if !yield(page, nil) || uint(rows) < p.size {
+				return
+			}
+		}
+	}
+}
+
+func (p *Paginator) execute(ctx context.Context, query string) (Result, error) {
+	resp, err := p.executor.Execute(ctx, query)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := resp.Error(); err != nil {
+		return Result{}, err
+	}
+
+	if len(resp.Results) == 0 {
+		return Result{}, nil
+	}
+
+	return resp.Results[0], resp.Results[0].Error()
+}
+
+// nextCursor cursor after a page, counting the rows at the time of its last
+// row, plus those of the previous cursor when the whole page is at that time
+func nextCursor(result Result, previous *Cursor) (*Cursor, error) {
+	var series Series
+	for _, s := range result.Series {
+		if len(s.Values) > 0 {
+			series = s
+		}
+	}
+
+	rows := series.Values
+	if len(series.Columns) == 0 || series.Columns[0] != "time" {
+		return nil, ErrPageCursor
+	}
+
+	var ties uint
+	for i := len(rows) - 1; i >= 0 && len(rows[i]) > 0 && rows[i][0] == rows[len(rows)-1][0]; i-- {
+		ties++
+	}
+
+	if ties == 0 {
+		return nil, ErrPageCursor
+	}
+
+	t, err := cursorTime(rows[len(rows)-1][0])
+	if err != nil {
+		return nil, err
+	}
+
+	if previous != nil && previous.Time == t && int(ties) == len(rows) {
+		ties += previous.Ties
+	}
+
+	return &Cursor{Time: t, Ties: ties}, nil
+}
+
+// cursorTime time of a row as a criteria value, epochs compare as integers
+func cursorTime(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+	case float64:
+		// Nanosecond epochs are beyond the 53 bits of a float64 mantissa
+		if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+			return int64(v), nil
+		}
+
+		return nil, fmt.Errorf("epoch %v decoded as float64, decode it as json.Number: %w", v, ErrPageCursor)
+	case int64, int:
+		return v, nil
+	}
+
+	return nil, ErrPageCursor
+}
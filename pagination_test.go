@@ -0,0 +1,217 @@
+package influxquerybuilder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func pageResponse(times ...interface{}) *Response {
+	values := make([][]interface{}, len(times))
+	for i, t := range times {
+		values[i] = []interface{}{t, float64(i)}
+	}
+
+	return &Response{Results: []Result{{Series: []Series{{Name: "cpu", Columns: []string{"time", "value"}, Values: values}}}}}
+}
+
+func collectPages(t *testing.T, p *Paginator) []int {
+	var rows []int
+
+	for page, err := range p.Pages(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rows = append(rows, page.Rows())
+	}
+
+	return rows
+}
+
+func TestPaginateKeyset(t *testing.T) {
+	executor := &scriptedExecutor{responses: []*Response{
+		pageResponse("2020-01-01T00:00:01Z", "2020-01-01T00:00:02Z"),
+		pageResponse("2020-01-01T00:00:03Z", "2020-01-01T00:00:04Z"),
+		pageResponse("2020-01-01T00:00:05Z"),
+	}}
+
+	q := New().Select("value").From("cpu").Where("host", "=", "a")
+	rows := collectPages(t, Paginate(q, executor, 2))
+
+	assert(t, reflect.DeepEqual(rows, []int{2, 2, 1}), true)
+	assert(t, reflect.DeepEqual(executor.queries, []string{
+		`SELECT "value" FROM "cpu" WHERE "host" = 'a' LIMIT 2`,
+		`SELECT "value" FROM "cpu" WHERE "host" = 'a' AND "time" >= '2020-01-01T00:00:02Z' LIMIT 2 OFFSET 1`,
+		`SELECT "value" FROM "cpu" WHERE "host" = 'a' AND "time" >= '2020-01-01T00:00:04Z' LIMIT 2 OFFSET 1`,
+	}), true)
+
+	// The original query is left as it is
+	assert(t, q.Build(), `SELECT "value" FROM "cpu" WHERE "host" = 'a'`)
+}
+
+func TestPaginateKeysetDesc(t *testing.T) {
+	executor := &scriptedExecutor{responses: []*Response{
+		pageResponse(int64(9), int64(8)),
+		pageResponse(int64(7), int64(6)),
+		pageResponse(),
+	}}
+
+	q := New().Select("value").From("cpu").Where("host", "=", "a").Or("host", "=", "b").Desc()
+	rows := collectPages(t, Paginate(q, executor, 2))
+
+	assert(t, reflect.DeepEqual(rows, []int{2, 2}), true)
+	assert(t, reflect.DeepEqual(executor.queries, []string{
+		`SELECT "value" FROM "cpu" WHERE "host" = 'a' OR "host" = 'b' ORDER BY time DESC LIMIT 2`,
+		`SELECT "value" FROM "cpu" WHERE ("host" = 'a' OR "host" = 'b') AND "time" <= 8 ORDER BY time DESC LIMIT 2 OFFSET 1`,
+		`SELECT "value" FROM "cpu" WHERE ("host" = 'a' OR "host" = 'b') AND "time" <= 6 ORDER BY time DESC LIMIT 2 OFFSET 1`,
+	}), true)
+}
+
+func TestPaginateKeysetTies(t *testing.T) {
+	executor := &scriptedExecutor{responses: []*Response{
+		pageResponse(json.Number("1"), json.Number("2"), json.Number("2")),
+		pageResponse(json.Number("2"), json.Number("2"), json.Number("2")),
+		pageResponse(json.Number("2"), json.Number("3")),
+	}}
+
+	q := New().Select("value").From("cpu").Offset(10)
+	rows := collectPages(t, Paginate(q, executor, 3))
+
+	assert(t, reflect.DeepEqual(rows, []int{3, 3, 2}), true)
+	assert(t, reflect.DeepEqual(executor.queries, []string{
+		`SELECT "value" FROM "cpu" LIMIT 3 OFFSET 10`,
+		`SELECT "value" FROM "cpu" WHERE "time" >= 2 LIMIT 3 OFFSET 2`,
+		`SELECT "value" FROM "cpu" WHERE "time" >= 2 LIMIT 3 OFFSET 5`,
+	}), true)
+}
+
+func TestPaginateOffset(t *testing.T) {
+	executor := &scriptedExecutor{responses: []*Response{
+		pageResponse("a", "b"),
+		pageResponse("c"),
+	}}
+
+	q := New().Select("value").From("cpu").GroupByTag("host")
+	rows := collectPages(t, Paginate(q, executor, 2).UseOffset())
+
+	assert(t, reflect.DeepEqual(rows, []int{2, 1}), true)
+	assert(t, reflect.DeepEqual(executor.queries, []string{
+		`SELECT "value" FROM "cpu" GROUP BY host LIMIT 2 OFFSET 0`,
+		`SELECT "value" FROM "cpu" GROUP BY host LIMIT 2 OFFSET 2`,
+	}), true)
+
+	executor = &scriptedExecutor{responses: []*Response{pageResponse("a")}}
+	collectPages(t, Paginate(q.Offset(5), executor, 2).UseOffset())
+	assert(t, executor.queries[0], `SELECT "value" FROM "cpu" GROUP BY host LIMIT 2 OFFSET 5`)
+}
+
+func TestPaginateLimit(t *testing.T) {
+	executor := &scriptedExecutor{responses: []*Response{
+		pageResponse("2020-01-01T00:00:01Z", "2020-01-01T00:00:02Z"),
+		pageResponse("2020-01-01T00:00:03Z", "2020-01-01T00:00:04Z"),
+		pageResponse("2020-01-01T00:00:05Z"),
+	}}
+
+	q := New().Select("value").From("cpu").Limit(5)
+	rows := collectPages(t, Paginate(q, executor, 2))
+
+	assert(t, reflect.DeepEqual(rows, []int{2, 2, 1}), true)
+	assert(t, reflect.DeepEqual(executor.queries, []string{
+		`SELECT "value" FROM "cpu" LIMIT 2`,
+		`SELECT "value" FROM "cpu" WHERE "time" >= '2020-01-01T00:00:02Z' LIMIT 2 OFFSET 1`,
+		`SELECT "value" FROM "cpu" WHERE "time" >= '2020-01-01T00:00:04Z' LIMIT 1 OFFSET 1`,
+	}), true)
+
+	executor = &scriptedExecutor{responses: []*Response{
+		pageResponse("a", "b"),
+		pageResponse("c", "d"),
+	}}
+
+	q = New().Select("value").From("cpu").GroupByTag("host").Limit(4)
+	rows = collectPages(t, Paginate(q, executor, 2).UseOffset())
+
+	assert(t, reflect.DeepEqual(rows, []int{2, 2}), true)
+	assert(t, len(executor.queries), 2)
+
+	_, err := Paginate(q, executor, 2).UseOffset().Query(2, nil)
+	assert(t, errors.Is(err, ErrPageLimit), true)
+}
+
+// wrappedBuilder a QueryBuilder other than *Query
+type wrappedBuilder struct {
+	QueryBuilder
+	spec *QuerySpec
+}
+
+func (w wrappedBuilder) ToSpec() QuerySpec {
+	if w.spec != nil {
+		return *w.spec
+	}
+
+	return w.QueryBuilder.ToSpec()
+}
+
+func TestPaginateBuilder(t *testing.T) {
+	p := Paginate(wrappedBuilder{QueryBuilder: New().Select("value").From("cpu")}, &scriptedExecutor{}, 2)
+
+	q, err := p.Query(1, &Cursor{Time: "2020-01-01T00:00:00Z"})
+	assert(t, err, nil)
+	assert(t, q.Build(), `SELECT "value" FROM "cpu" WHERE "time" >= '2020-01-01T00:00:00Z' LIMIT 2`)
+
+	p = Paginate(wrappedBuilder{QueryBuilder: New(), spec: &QuerySpec{Version: 99}}, &scriptedExecutor{}, 2)
+
+	_, err = p.Query(0, nil)
+	assert(t, errors.Is(err, ErrSpecVersion), true)
+
+	for _, err := range p.Pages(context.Background()) {
+		assert(t, errors.Is(err, ErrSpecVersion), true)
+	}
+}
+
+func TestPaginateStop(t *testing.T) {
+	executor := &scriptedExecutor{responses: []*Response{
+		pageResponse("2020-01-01T00:00:01Z", "2020-01-01T00:00:02Z"),
+		pageResponse("2020-01-01T00:00:03Z", "2020-01-01T00:00:04Z"),
+	}}
+
+	for page := range Paginate(New().Select("value").From("cpu"), executor, 2).Pages(context.Background()) {
+		assert(t, page.Number, 0)
+		break
+	}
+
+	assert(t, len(executor.queries), 1)
+}
+
+func TestPaginateErrors(t *testing.T) {
+	q := New().Select("value").From("cpu").GroupByTag("host")
+
+	for _, err := range Paginate(q, &scriptedExecutor{}, 2).Pages(context.Background()) {
+		assert(t, errors.Is(err, ErrUnsupported), true)
+	}
+
+	executor := &scriptedExecutor{responses: []*Response{{Results: []Result{{Err: "timeout"}}}}}
+	for _, err := range Paginate(New().Select("value").From("cpu"), executor, 2).Pages(context.Background()) {
+		assert(t, err.Error(), "timeout")
+	}
+
+	executor = &scriptedExecutor{responses: []*Response{{Results: []Result{{Series: []Series{
+		{Name: "cpu", Columns: []string{"value"}, Values: [][]interface{}{{1.0}, {2.0}}},
+	}}}}}}
+	for _, err := range Paginate(New().Select("value").From("cpu"), executor, 2).Pages(context.Background()) {
+		assert(t, err, ErrPageCursor)
+	}
+
+	executor = &scriptedExecutor{responses: []*Response{pageResponse(float64(1577836800000000000), float64(1577836800000000001))}}
+	for _, err := range Paginate(New().Select("value").From("cpu"), executor, 2).Pages(context.Background()) {
+		assert(t, errors.Is(err, ErrPageCursor), true)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, err := range Paginate(New().Select("value").From("cpu"), executor, 2).Pages(ctx) {
+		assert(t, err, context.Canceled)
+	}
+}
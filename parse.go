@@ -0,0 +1,973 @@
+package influxquerybuilder
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseError Parse error with the byte offset of the offending token
+type ParseError struct {
+	Pos int
+	Msg string
+}
+
+// Error Error interface
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("influxquerybuilder: parse error at %d: %s", e.Pos, e.Msg)
+}
+
+// Parse Parse a SELECT statement into a QueryBuilder. Only the InfluxQL
+// subset the builder can express is supported, criteria are regrouped with
+// brackets where needed to keep their precedence.
+func Parse(query string) (QueryBuilder, error) {
+	p := &parser{lexer: &lexer{src: query}}
+
+	q, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokQuotedIdent
+	tokString
+	tokNumber
+	tokDuration
+	tokRegex
+	tokOperator
+	tokPunct
+	tokVariable
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+	end  int
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.src) && unicode.IsSpace(rune(l.src[l.pos])) {
+		l.pos++
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipSpace()
+
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: start, end: start}, nil
+	}
+
+	c := l.src[l.pos]
+
+	switch {
+	case c == '"' || c == '\'':
+		text, err := l.scanQuoted(c)
+		if err != nil {
+			return token{}, err
+		}
+
+		kind := tokString
+		if c == '"' {
+			kind = tokQuotedIdent
+		}
+
+		return token{kind: kind, text: text, pos: start, end: l.pos}, nil
+	case c >= '0' && c <= '9':
+		for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+			l.pos++
+		}
+
+		// Exponent of floats such as 1e+06, as the builder renders them
+		if exp := l.exponent(); exp > 0 {
+			l.pos += exp
+			return token{kind: tokNumber, text: l.src[start:l.pos], pos: start, end: l.pos}, nil
+		}
+
+		kind := tokNumber
+		if unitStart := l.pos; l.pos < len(l.src) && isLetter(l.src[l.pos]) {
+			for l.pos < len(l.src) && isLetter(l.src[l.pos]) {
+				l.pos++
+			}
+
+			if _, ok := durationUnits[l.src[unitStart:l.pos]]; !ok {
+				return token{}, &ParseError{start, fmt.Sprintf("invalid duration %s", l.src[start:l.pos])}
+			}
+
+			kind = tokDuration
+		}
+
+		return token{kind: kind, text: l.src[start:l.pos], pos: start, end: l.pos}, nil
+	case isLetter(c) || c == '_':
+		for l.pos < len(l.src) && (isLetter(l.src[l.pos]) || isDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+			l.pos++
+		}
+
+		return token{kind: tokIdent, text: l.src[start:l.pos], pos: start, end: l.pos}, nil
+	case c == '$':
+		return l.scanVariable()
+	}
+
+	for _, op := range []string{"=~", "!~", "!=", "<>", "<=", ">=", "=", "<", ">"} {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			return token{kind: tokOperator, text: op, pos: start, end: l.pos}, nil
+		}
+	}
+
+	if strings.HasPrefix(l.src[l.pos:], "::") {
+		l.pos += 2
+		return token{kind: tokPunct, text: "::", pos: start, end: l.pos}, nil
+	}
+
+	l.pos++
+
+	return token{kind: tokPunct, text: string(c), pos: start, end: l.pos}, nil
+}
+
+// exponent length of the exponent of a float at the current position, 0
+// without one
+func (l *lexer) exponent() int {
+	i := l.pos
+	if i >= len(l.src) || l.src[i] != 'e' && l.src[i] != 'E' {
+		return 0
+	}
+	i++
+
+	if i < len(l.src) && (l.src[i] == '+' || l.src[i] == '-') {
+		i++
+	}
+
+	digits := i
+	for i < len(l.src) && isDigit(l.src[i]) {
+		i++
+	}
+
+	if i == digits {
+		return 0
+	}
+
+	return i - l.pos
+}
+
+// scanVariable scans a $name or ${name} template variable
+func (l *lexer) scanVariable() (token, error) {
+	start := l.pos
+	l.pos++
+
+	braces := l.pos < len(l.src) && l.src[l.pos] == '{'
+	if braces {
+		l.pos++
+	}
+
+	nameStart := l.pos
+	for l.pos < len(l.src) && (isLetter(l.src[l.pos]) || isDigit(l.src[l.pos]) || l.src[l.pos] == '_') {
+		l.pos++
+	}
+
+	if l.pos == nameStart {
+		return token{}, &ParseError{start, "expected variable name"}
+	}
+
+	if braces {
+		if l.pos >= len(l.src) || l.src[l.pos] != '}' {
+			return token{}, &ParseError{l.pos, "expected }"}
+		}
+		l.pos++
+	}
+
+	return token{kind: tokVariable, text: l.src[start:l.pos], pos: start, end: l.pos}, nil
+}
+
+// scanQuoted scans a quoted identifier or string, resolving escapes
+func (l *lexer) scanQuoted(quote byte) (string, error) {
+	start := l.pos
+	l.pos++
+
+	var b strings.Builder
+
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+
+		switch {
+		case c == '\\' && l.pos+1 < len(l.src):
+			next := l.src[l.pos+1]
+			if next == quote || next == '\\' {
+				b.WriteByte(next)
+			} else {
+				b.WriteByte(c)
+				b.WriteByte(next)
+			}
+			l.pos += 2
+		case c == quote:
+			l.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+
+	return "", &ParseError{start, "unterminated quote"}
+}
+
+// scanRegex scans a /regex/ literal, keeping escaped slashes
+func (l *lexer) scanRegex() (token, error) {
+	l.skipSpace()
+
+	start := l.pos
+	if l.pos >= len(l.src) || l.src[l.pos] != '/' {
+		return token{}, &ParseError{start, "expected regex"}
+	}
+
+	l.pos++
+
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos += 2
+		case '/':
+			l.pos++
+			return token{kind: tokRegex, text: l.src[start+1 : l.pos-1], pos: start, end: l.pos}, nil
+		default:
+			l.pos++
+		}
+	}
+
+	return token{}, &ParseError{start, "unterminated regex"}
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+var durationUnits = map[string]func(Duration, uint) Duration{
+	"ns": Duration.Nanoseconds,
+	"u":  Duration.Microseconds,
+	"ms": Duration.Milliseconds,
+	"s":  Duration.Second,
+	"m":  Duration.Minute,
+	"h":  Duration.Hour,
+	"d":  Duration.Day,
+	"w":  Duration.Week,
+}
+
+// ParseDuration Parse a duration literal such as 10m
+func ParseDuration(literal string) (Duration, error) {
+	i := strings.IndexFunc(literal, func(r rune) bool { return !unicode.IsDigit(r) })
+	if i <= 0 {
+		return nil, fmt.Errorf("influxquerybuilder: invalid duration %q", literal)
+	}
+
+	unit, ok := durationUnits[literal[i:]]
+	if !ok {
+		return nil, fmt.Errorf("influxquerybuilder: invalid duration %q", literal)
+	}
+
+	value, err := strconv.ParseUint(literal[:i], 10, 0)
+	if err != nil {
+		return nil, fmt.Errorf("influxquerybuilder: invalid duration %q", literal)
+	}
+
+	return unit(NewDuration(), uint(value)), nil
+}
+
+type parser struct {
+	lexer  *lexer
+	peeked *token
+}
+
+func (p *parser) peek() (token, error) {
+	if p.peeked == nil {
+		tok, err := p.lexer.next()
+		if err != nil {
+			return token{}, err
+		}
+
+		p.peeked = &tok
+	}
+
+	return *p.peeked, nil
+}
+
+func (p *parser) next() (token, error) {
+	tok, err := p.peek()
+	p.peeked = nil
+
+	return tok, err
+}
+
+// keyword consumes the next token if it is the given keyword
+func (p *parser) keyword(kw string) (bool, error) {
+	tok, err := p.peek()
+	if err != nil {
+		return false, err
+	}
+
+	if tok.kind == tokIdent && strings.EqualFold(tok.text, kw) {
+		p.peeked = nil
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	ok, err := p.keyword(kw)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		tok, _ := p.peek()
+		return &ParseError{tok.pos, fmt.Sprintf("expected %s", kw)}
+	}
+
+	return nil
+}
+
+// punct consumes the next token if it is the given punctuation
+func (p *parser) punct(text string) (bool, error) {
+	tok, err := p.peek()
+	if err != nil {
+		return false, err
+	}
+
+	if tok.kind == tokPunct && tok.text == text {
+		p.peeked = nil
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (p *parser) identifier() (string, error) {
+	tok, err := p.next()
+	if err != nil {
+		return "", err
+	}
+
+	if tok.kind != tokIdent && tok.kind != tokQuotedIdent {
+		return "", &ParseError{tok.pos, "expected identifier"}
+	}
+
+	return tok.text, nil
+}
+
+// key parses an identifier with an optional ::type cast
+func (p *parser) key() (string, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return "", err
+	}
+
+	ok, err := p.punct("::")
+	if err != nil || !ok {
+		return name, err
+	}
+
+	t, err := p.identifier()
+	if err != nil {
+		return "", err
+	}
+
+	return Cast(name, KeyType(strings.ToLower(t))), nil
+}
+
+func (p *parser) parseSelect() (QueryBuilder, error) {
+	q := New()
+
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+
+	fields, err := p.parseFields()
+	if err != nil {
+		return nil, err
+	}
+
+	q.Select(fields...)
+
+	if ok, err := p.keyword("INTO"); err != nil {
+		return nil, err
+	} else if ok {
+		rp, m, err := p.parseMeasurement()
+		if err != nil {
+			return nil, err
+		}
+
+		q.IntoRP(rp, m)
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+
+	rp, m, err := p.parseMeasurement()
+	if err != nil {
+		return nil, err
+	}
+
+	q.FromRP(rp, m)
+
+	if ok, err := p.keyword("WHERE"); err != nil {
+		return nil, err
+	} else if ok {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		applyExpr(q, expr)
+	}
+
+	if err := p.parseTail(q); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.punct(";"); err != nil {
+		return nil, err
+	}
+
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok.kind != tokEOF {
+		return nil, &ParseError{tok.pos, fmt.Sprintf("unexpected %s", tok.text)}
+	}
+
+	return q, nil
+}
+
+func (p *parser) parseFields() ([]string, error) {
+	var fields []string
+
+	for {
+		field, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, field)
+
+		if ok, err := p.punct(","); err != nil {
+			return nil, err
+		} else if !ok {
+			return fields, nil
+		}
+	}
+}
+
+func (p *parser) parseField() (string, error) {
+	if ok, err := p.punct("*"); err != nil {
+		return "", err
+	} else if ok {
+		return "*", nil
+	}
+
+	tok, err := p.peek()
+	if err != nil {
+		return "", err
+	}
+
+	var field string
+
+	if tok.kind == tokIdent && p.isCall() {
+		field, err = p.parseCall()
+	} else {
+		field, err = p.key()
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if ok, err := p.keyword("AS"); err != nil {
+		return "", err
+	} else if ok {
+		alias, err := p.identifier()
+		if err != nil {
+			return "", err
+		}
+
+		field = fmt.Sprintf("%s AS %s", field, alias)
+	}
+
+	return field, nil
+}
+
+// isCall reports whether the peeked identifier is followed by "("
+func (p *parser) isCall() bool {
+	rest := strings.TrimLeftFunc(p.lexer.src[p.lexer.pos:], unicode.IsSpace)
+
+	return strings.HasPrefix(rest, "(")
+}
+
+// parseCall returns the raw source of a function call
+func (p *parser) parseCall() (string, error) {
+	name, err := p.next()
+	if err != nil {
+		return "", err
+	}
+
+	depth := 0
+
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case tok.kind == tokEOF:
+			return "", &ParseError{tok.pos, "unterminated function call"}
+		case tok.kind == tokPunct && tok.text == "(":
+			depth++
+		case tok.kind == tokPunct && tok.text == ")":
+			depth--
+		}
+
+		if depth == 0 {
+			return p.lexer.src[name.pos:tok.end], nil
+		}
+	}
+}
+
+func (p *parser) parseMeasurement() (string, string, error) {
+	first, err := p.identifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	if ok, err := p.punct("."); err != nil || !ok {
+		return "", first, err
+	}
+
+	second, err := p.identifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	if ok, _ := p.punct("."); ok {
+		tok, _ := p.peek()
+		return "", "", &ParseError{tok.pos, "database qualified measurements are not supported"}
+	}
+
+	return first, second, nil
+}
+
+// expr Parsed criteria, either a comparison or an AND/OR group
+type expr struct {
+	op    string
+	tag   Tag
+	terms []*expr
+}
+
+func (p *parser) parseOr() (*expr, error) {
+	return p.parseGroup("OR", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (*expr, error) {
+	return p.parseGroup("AND", p.parseTerm)
+}
+
+func (p *parser) parseGroup(op string, term func() (*expr, error)) (*expr, error) {
+	first, err := term()
+	if err != nil {
+		return nil, err
+	}
+
+	group := &expr{op: op, terms: []*expr{first}}
+
+	for {
+		ok, err := p.keyword(op)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		t, err := term()
+		if err != nil {
+			return nil, err
+		}
+
+		group.terms = append(group.terms, t)
+	}
+
+	if len(group.terms) == 1 {
+		return first, nil
+	}
+
+	return group, nil
+}
+
+func (p *parser) parseTerm() (*expr, error) {
+	if ok, err := p.punct("("); err != nil {
+		return nil, err
+	} else if ok {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if ok, err := p.punct(")"); err != nil {
+			return nil, err
+		} else if !ok {
+			tok, _ := p.peek()
+			return nil, &ParseError{tok.pos, "expected )"}
+		}
+
+		return e, nil
+	}
+
+	if tok, err := p.peek(); err != nil {
+		return nil, err
+	} else if tok.kind == tokVariable {
+		p.next()
+		return &expr{tag: Tag{"", "", variableOf(tok)}}, nil
+	}
+
+	key, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if op.kind != tokOperator {
+		return nil, &ParseError{op.pos, "expected operator"}
+	}
+
+	value, err := p.parseValue(Operator(op.text).IsRegex())
+	if err != nil {
+		return nil, err
+	}
+
+	return &expr{tag: Tag{key, op.text, value}}, nil
+}
+
+func (p *parser) parseValue(regex bool) (interface{}, error) {
+	if regex {
+		if p.peeked != nil {
+			return nil, &ParseError{p.peeked.pos, "expected regex"}
+		}
+
+		tok, err := p.lexer.scanRegex()
+		if err != nil {
+			return nil, err
+		}
+
+		// /^$name$/ is how Grafana matches multi-value variables
+		if match := regexVariableMatcher.FindStringSubmatch(tok.text); match != nil {
+			return Var(match[1]), nil
+		}
+
+		re, err := regexp.Compile(tok.text)
+		if err != nil {
+			return nil, &ParseError{tok.pos, err.Error()}
+		}
+
+		return re, nil
+	}
+
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+
+	switch {
+	case tok.kind == tokString:
+		value = tok.text
+	case tok.kind == tokNumber:
+		value, err = parseNumber(tok)
+	case tok.kind == tokVariable:
+		value = variableOf(tok)
+	case tok.kind == tokPunct && tok.text == "-":
+		var num token
+		if num, err = p.next(); err != nil {
+			return nil, err
+		}
+
+		if num.kind != tokNumber {
+			return nil, &ParseError{num.pos, "expected number"}
+		}
+
+		num.text = "-" + num.text
+		value, err = parseNumber(num)
+	case tok.kind == tokIdent && (strings.EqualFold(tok.text, "true") || strings.EqualFold(tok.text, "false")):
+		value = strings.EqualFold(tok.text, "true")
+	case tok.kind == tokIdent && strings.EqualFold(tok.text, "now"):
+		return nil, &ParseError{tok.pos, "now() is not representable by the builder, use an RFC3339 time"}
+	default:
+		return nil, &ParseError{tok.pos, fmt.Sprintf("unsupported value %s", tok.text)}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if next, _ := p.peek(); next.kind == tokPunct && strings.Contains("+-*/", next.text) {
+		return nil, &ParseError{next.pos, "arithmetic values are not supported"}
+	}
+
+	return value, nil
+}
+
+var regexVariableMatcher = regexp.MustCompile(`^\^\$\{?(\w+)\}?\$$`)
+
+func variableOf(tok token) Variable {
+	return Var(strings.Trim(tok.text, "${}"))
+}
+
+func parseNumber(tok token) (interface{}, error) {
+	if i, err := strconv.Atoi(tok.text); err == nil {
+		return i, nil
+	}
+
+	f, err := strconv.ParseFloat(tok.text, 64)
+	if err != nil {
+		return nil, &ParseError{tok.pos, fmt.Sprintf("invalid number %s", tok.text)}
+	}
+
+	return f, nil
+}
+
+// applyExpr sets the criteria of a builder from an expression, wrapping
+// nested groups in brackets
+func applyExpr(q QueryBuilder, e *expr) {
+	if e.terms == nil {
+		q.Where(e.tag.key, e.tag.op, e.tag.value)
+		return
+	}
+
+	for i, t := range e.terms {
+		switch {
+		case i == 0 && t.terms == nil:
+			q.Where(t.tag.key, t.tag.op, t.tag.value)
+		case i == 0:
+			q.WhereBrackets(bracketOf(t))
+		case t.terms == nil && e.op == "AND":
+			q.And(t.tag.key, t.tag.op, t.tag.value)
+		case t.terms == nil:
+			q.Or(t.tag.key, t.tag.op, t.tag.value)
+		case e.op == "AND":
+			q.AndBrackets(bracketOf(t))
+		default:
+			q.OrBrackets(bracketOf(t))
+		}
+	}
+}
+
+func bracketOf(e *expr) QueryBuilder {
+	b := New()
+	applyExpr(b, e)
+
+	return b
+}
+
+func (p *parser) parseTail(q QueryBuilder) error {
+	if ok, err := p.keyword("GROUP"); err != nil {
+		return err
+	} else if ok {
+		if err := p.expectKeyword("BY"); err != nil {
+			return err
+		}
+
+		if err := p.parseGroupBy(q); err != nil {
+			return err
+		}
+	}
+
+	if ok, err := p.keyword("FILL"); err != nil {
+		return err
+	} else if ok {
+		if err := p.parseFill(q); err != nil {
+			return err
+		}
+	}
+
+	if ok, err := p.keyword("ORDER"); err != nil {
+		return err
+	} else if ok {
+		if err := p.expectKeyword("BY"); err != nil {
+			return err
+		}
+
+		if err := p.expectKeyword("time"); err != nil {
+			return err
+		}
+
+		if ok, err := p.keyword("DESC"); err != nil {
+			return err
+		} else if ok {
+			q.Desc()
+		} else {
+			p.keyword("ASC")
+			q.Asc()
+		}
+	}
+
+	if ok, err := p.keyword("LIMIT"); err != nil {
+		return err
+	} else if ok {
+		n, err := p.parseUint()
+		if err != nil {
+			return err
+		}
+
+		q.Limit(n)
+	}
+
+	if ok, err := p.keyword("OFFSET"); err != nil {
+		return err
+	} else if ok {
+		n, err := p.parseUint()
+		if err != nil {
+			return err
+		}
+
+		q.Offset(n)
+	}
+
+	return nil
+}
+
+func (p *parser) parseGroupBy(q QueryBuilder) error {
+	for {
+		tok, err := p.peek()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case tok.kind == tokIdent && strings.EqualFold(tok.text, "time") && p.isCall():
+			p.next()
+			p.punct("(")
+
+			d, err := p.next()
+			if err != nil {
+				return err
+			}
+
+			var duration Duration
+
+			switch d.kind {
+			case tokVariable:
+				literal := d.text
+				// ${__interval_ms}ms
+				if unit, _ := p.peek(); unit.kind == tokIdent && unit.pos == d.end {
+					p.next()
+					literal += unit.text
+				}
+
+				duration = &variableDuration{literal}
+			case tokDuration:
+				if duration, err = ParseDuration(d.text); err != nil {
+					return &ParseError{d.pos, err.Error()}
+				}
+			default:
+				return &ParseError{d.pos, "expected duration"}
+			}
+
+			if ok, _ := p.punct(")"); !ok {
+				tok, _ := p.peek()
+				return &ParseError{tok.pos, "expected ), time offsets are not supported"}
+			}
+
+			q.GroupByTime(duration)
+		case tok.kind == tokPunct && tok.text == "*":
+			p.next()
+			q.GroupByTag("*")
+		default:
+			tag, err := p.identifier()
+			if err != nil {
+				return err
+			}
+
+			q.GroupByTag(tag)
+		}
+
+		if ok, err := p.punct(","); err != nil {
+			return err
+		} else if !ok {
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseFill(q QueryBuilder) error {
+	if ok, _ := p.punct("("); !ok {
+		tok, _ := p.peek()
+		return &ParseError{tok.pos, "expected ("}
+	}
+
+	tok, err := p.next()
+	if err != nil {
+		return err
+	}
+
+	switch tok.kind {
+	case tokIdent:
+		q.Fill(strings.ToLower(tok.text))
+	case tokNumber:
+		n, err := parseNumber(tok)
+		if err != nil {
+			return err
+		}
+
+		q.Fill(n)
+	default:
+		return &ParseError{tok.pos, "expected fill option"}
+	}
+
+	if ok, _ := p.punct(")"); !ok {
+		tok, _ := p.peek()
+		return &ParseError{tok.pos, "expected )"}
+	}
+
+	return nil
+}
+
+func (p *parser) parseUint() (uint, error) {
+	tok, err := p.next()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.ParseUint(tok.text, 10, 0)
+	if tok.kind != tokNumber || err != nil {
+		return 0, &ParseError{tok.pos, "expected a positive integer"}
+	}
+
+	return uint(n), nil
+}
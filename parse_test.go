@@ -0,0 +1,107 @@
+package influxquerybuilder
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func assertParse(t *testing.T, query string, expected string) {
+	q, err := Parse(query)
+	if err != nil {
+		t.Fatalf("Parse %s: %s", query, err)
+	}
+
+	assert(t, q.Build(), expected)
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	for _, query := range []string{
+		`SELECT "temperature","humidity" FROM "measurement"`,
+		`SELECT * FROM rp_1h."measurement"`,
+		`SELECT "temperature" AS "temp",MEAN("humidity") AS "hum" FROM "measurement"`,
+		`SELECT MEAN("temperature") INTO rp_1y."temperature_1h" FROM "measurement" GROUP BY time(1h),sensorId`,
+		`SELECT "temperature" FROM "measurement" WHERE "time" > '2018-11-01T06:33:57.503Z' AND "time" < '2018-11-02T09:35:25Z'`,
+		`SELECT "temperature" FROM "measurement" WHERE "temperature" > 20 OR "humidity" < 10.101 OR "hot" = true`,
+		`SELECT "temperature" FROM "measurement" WHERE "host" =~ /^web\/\d+/ AND "cpu"::tag = '0'`,
+		`SELECT "temperature" FROM "measurement" WHERE "time" > '2018-11-01T06:33:57.503Z' AND ("time" < '2018-11-02T09:35:25Z' OR "tag" = 't')`,
+		`SELECT MEAN("temperature") FROM "measurement" GROUP BY time(10m) FILL(previous) ORDER BY time DESC LIMIT 10 OFFSET 5`,
+		`SELECT "temperature" FROM "measurement" FILL(0) ORDER BY time ASC`,
+	} {
+		assertParse(t, query, query)
+	}
+}
+
+// TestParseBuiltValues parses back each kind of value the builder renders
+func TestParseBuiltValues(t *testing.T) {
+	for _, value := range []interface{}{
+		"text", "it's", 0, -3, int64(1) << 40, 1.5, -0.25, 1e6, 1e21, 1.5e-7, -2e+10, true, false,
+		regexp.MustCompile(`^web/\d+`), Var("host"),
+	} {
+		op := "="
+		if _, ok := value.(*regexp.Regexp); ok {
+			op = "=~"
+		}
+
+		query := New().Select("v").From("m").Where("k", op, value).Build()
+		assertParse(t, query, query)
+	}
+}
+
+func TestParseCanonical(t *testing.T) {
+	assertParse(t,
+		"select temperature, \"humidity\"\n  from measurement\n  where \"a\" = 'x' and b != -1.5\n  limit 10;",
+		`SELECT "temperature","humidity" FROM "measurement" WHERE "a" = 'x' AND "b" != -1.5 LIMIT 10`,
+	)
+
+	// AND binds tighter than OR, the AND group is bracketed
+	assertParse(t,
+		`SELECT "v" FROM "m" WHERE "a" = 1 OR "b" = 2 AND "c" = 3`,
+		`SELECT "v" FROM "m" WHERE "a" = 1 OR ("b" = 2 AND "c" = 3)`,
+	)
+
+	assertParse(t,
+		`SELECT "v" FROM "m" WHERE ("a" = 1 OR "b" = 2) AND "c" = 'it\'s'`,
+		`SELECT "v" FROM "m" WHERE ("a" = 1 OR "b" = 2) AND "c" = 'it\'s'`,
+	)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, query := range []string{
+		``,
+		`SHOW USERS`,
+		`SELECT "v"`,
+		`SELECT "v" FROM "m" WHERE "time" > now() - 1h`,
+		`SELECT "v" FROM "m" WHERE "a" = 'unterminated`,
+		`SELECT "v" FROM "m" WHERE "a" =~ 'x'`,
+		`SELECT "v" FROM "m" GROUP BY time(10m, 5m)`,
+		`SELECT "v" FROM "m" GROUP BY time(10x)`,
+		`SELECT "v" FROM "m" LIMIT -1`,
+		`SELECT "v" FROM "m" SLIMIT 1`,
+		`SELECT "v" FROM db.rp.m`,
+		`SELECT "v" FROM "m" LIMIT 1 "unterminated`,
+		`SELECT "v" FROM "m"; SELECT "w" FROM "m"`,
+	} {
+		if _, err := Parse(query); err == nil {
+			t.Errorf("Expected parse error for %s", query)
+		} else if _, ok := err.(*ParseError); !ok {
+			t.Errorf("Expected *ParseError for %s but got %T", query, err)
+		}
+	}
+}
+
+func TestParseNow(t *testing.T) {
+	_, err := Parse(`SELECT "v" FROM "m" WHERE "time" > now() - 1h`)
+	if err == nil || !strings.Contains(err.Error(), "now() is not representable") {
+		t.Errorf("Expected a now() error but got %v", err)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	d, err := ParseDuration("15ms")
+	assert(t, err, nil)
+	assert(t, d.getLiteral(), "15ms")
+
+	_, err = ParseDuration("15")
+	assert(t, err != nil, true)
+}
@@ -0,0 +1,121 @@
+package influxquerybuilder
+
+import (
+	"bytes"
+	"strings"
+)
+
+// KeywordCase Case of keywords in pretty output
+type KeywordCase int
+
+// Keyword cases
+const (
+	UpperKeywords KeywordCase = iota
+	LowerKeywords
+)
+
+// PrettyOptions Options of BuildPretty
+type PrettyOptions struct {
+	// Indent Indentation of criteria, "  " if empty
+	Indent string
+	// Keywords Case of keywords
+	Keywords KeywordCase
+}
+
+// BuildPretty Build query string with one clause per line and indented
+// criteria and bracket groups. Joining the lines with single spaces, after
+// dropping the spaces inside brackets, gives the output of Build with
+// UpperKeywords.
+func (q *Query) BuildPretty(opts PrettyOptions) string {
+	if opts.Indent == "" {
+		opts.Indent = "  "
+	}
+
+	kw := func(keyword string) string {
+		if opts.Keywords == LowerKeywords {
+			return strings.ToLower(keyword)
+		}
+
+		return keyword
+	}
+
+	var lines []string
+
+	clause := func(compact string, keyword string) {
+		compact = strings.TrimSpace(compact)
+		if compact != "" {
+			lines = append(lines, kw(keyword)+strings.TrimPrefix(compact, keyword))
+		}
+	}
+
+	fields := strings.Replace(q.buildFields(), ` AS "`, ` `+kw("AS")+` "`, -1)
+	clause(fields, "SELECT")
+	clause(q.buildInto(), "INTO")
+	clause(q.buildFrom(), "FROM")
+
+	if where := q.prettyCriteria(opts.Indent, 0, kw); where != "" {
+		lines = append(lines, kw("WHERE")+" "+where)
+	}
+
+	clause(q.buildGroupBy(), "GROUP BY")
+	clause(q.buildFill(), "FILL")
+
+	if q.order != "" {
+		lines = append(lines, kw("ORDER BY")+" time "+kw(q.order))
+	}
+
+	clause(q.buildLimit(), "LIMIT")
+	clause(q.buildOffset(), "OFFSET")
+
+	return strings.Join(lines, "\n")
+}
+
+// prettyCriteria renders the criteria of buildWhere, one per line
+func (q *Query) prettyCriteria(indent string, depth int, kw func(string) string) string {
+	var buffer bytes.Buffer
+	prefix := "\n" + strings.Repeat(indent, depth+1)
+
+	brackets := func(b QueryBuilder) string {
+		if bq, ok := b.(*Query); ok {
+			inner := bq.prettyCriteria(indent, depth+1, kw)
+			return "(" + prefix + indent + inner + prefix + ")"
+		}
+
+		return "(" + strings.Replace(b.Build(), "WHERE ", "", 1) + ")"
+	}
+
+	switch {
+	case q.where != (Tag{}):
+		buffer.WriteString(getCriteriaTemplate(q.where))
+	case hasCriteria(q.whereBrackets):
+		buffer.WriteString(brackets(q.whereBrackets))
+	default:
+		return ""
+	}
+
+	for _, tag := range q.and {
+		buffer.WriteString(prefix + kw("AND") + " " + getCriteriaTemplate(tag))
+	}
+
+	for _, tag := range q.or {
+		buffer.WriteString(prefix + kw("OR") + " " + getCriteriaTemplate(tag))
+	}
+
+	for _, b := range q.andBrackets {
+		if !hasCriteria(b) {
+			continue
+		}
+
+		buffer.WriteString(prefix + kw("AND") + " " + brackets(b))
+	}
+
+	for _, b := range q.orBrackets {
+		if !hasCriteria(b) {
+			continue
+		}
+
+		buffer.WriteString(prefix + kw("OR") + " " + brackets(b))
+	}
+
+	return buffer.String()
+}
@@ -0,0 +1,98 @@
+package influxquerybuilder
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var prettyWhitespace = regexp.MustCompile(`\n\s*`)
+
+// compact joins pretty output back into the output of Build
+func compact(pretty string) string {
+	s := prettyWhitespace.ReplaceAllString(pretty, " ")
+	s = strings.Replace(s, "( ", "(", -1)
+
+	return strings.Replace(s, " )", ")", -1)
+}
+
+func prettyTestBuilder() QueryBuilder {
+	return New().
+		Select(`MEAN("temperature") AS temp`, "humidity::field AS hum").
+		IntoRP("rp_1y", "temperature_1h").
+		FromRP("rp_1h", "measurement").
+		Where("time", ">", "2018-11-01T06:33:57.503Z").
+		And("host", "=~", regexp.MustCompile("^web")).
+		Or("cpu", "=", 1).
+		AndBrackets(
+			New().
+				Where("region", "=", "eu").
+				OrBrackets(New().Where("zone", "=", "a").And("rack", "=", 2)),
+		).
+		GroupByTime(NewDuration().Minute(10)).
+		GroupByTag("host").
+		Fill("previous").
+		Desc().
+		Limit(10).
+		Offset(5)
+}
+
+func TestBuildPretty(t *testing.T) {
+	expected := `SELECT MEAN("temperature") AS "temp","humidity"::field AS "hum"
+INTO rp_1y."temperature_1h"
+FROM rp_1h."measurement"
+WHERE "time" > '2018-11-01T06:33:57.503Z'
+  AND "host" =~ /^web/
+  OR "cpu" = 1
+  AND (
+    "region" = 'eu'
+    OR (
+      "zone" = 'a'
+      AND "rack" = 2
+    )
+  )
+GROUP BY time(10m),host
+FILL(previous)
+ORDER BY time DESC
+LIMIT 10
+OFFSET 5`
+	builder := prettyTestBuilder()
+	q := builder.BuildPretty(PrettyOptions{})
+
+	assert(t, q, expected)
+	assert(t, compact(q), builder.Build())
+}
+
+func TestBuildPrettyOptions(t *testing.T) {
+	expected := `select "temperature"
+from "measurement"
+where "a" = 1
+	or (
+		"b" = 2
+		and "c" = 3
+	)
+order by time asc
+limit 1`
+	q := New().
+		Select("temperature").
+		From("measurement").
+		Where("a", "=", 1).
+		OrBrackets(New().Where("b", "=", 2).And("c", "=", 3)).
+		Asc().
+		Limit(1).
+		BuildPretty(PrettyOptions{Indent: "\t", Keywords: LowerKeywords})
+
+	assert(t, q, expected)
+}
+
+func TestBuildPrettyEquivalence(t *testing.T) {
+	for _, builder := range []QueryBuilder{
+		New(),
+		New().Select("*").From("measurement"),
+		New().Select("a").From("measurement").WhereBrackets(New().Where("b", "=", 1).Or("c", "=", 2)).And("d", "=", true),
+		New().Select("a").From("measurement").WhereIn("host", "x", "y", "z").GroupByTag("host"),
+		prettyTestBuilder(),
+	} {
+		assert(t, compact(builder.BuildPretty(PrettyOptions{})), builder.Build())
+	}
+}
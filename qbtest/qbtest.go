@@ -0,0 +1,278 @@
+// Package qbtest provides test helpers comparing InfluxQL by meaning rather
+// than by formatting:
+//
+//	qbtest.AssertEqual(t, builder.Build(), `SELECT value FROM cpu WHERE host='a' AND time > '2020-01-01T00:00:00Z'`)
+//	qbtest.Golden(t, "cpu_by_host", builder.Build())
+//
+// Queries are parsed and printed again by the builder, so whitespace, quoting
+// style and the order of commutative criteria and GROUP BY tags do not matter.
+// Run the tests with -qbtest.update, or set Update, to rewrite golden files.
+package qbtest
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+// Update Rewrite golden files instead of comparing with them, set by the
+// -qbtest.update flag. Packages with their own -update flag may set it
+var Update bool
+
+func init() {
+	flag.BoolVar(&Update, "qbtest.update", false, "rewrite golden files of qbtest.Golden")
+}
+
+// Normalize Canonical form of a query, as printed by the builder
+func Normalize(query string) (string, error) {
+	q, err := qb.Parse(query)
+	if err != nil {
+		return "", err
+	}
+
+	return q.Build(), nil
+}
+
+// Equal Whether two queries have the same meaning
+func Equal(a, b string) (bool, error) {
+	ka, err := semanticKey(a)
+	if err != nil {
+		return false, err
+	}
+
+	kb, err := semanticKey(b)
+	if err != nil {
+		return false, err
+	}
+
+	return ka == kb, nil
+}
+
+// AssertEqual Fail the test when the queries differ in meaning
+func AssertEqual(t testing.TB, got, expected string) {
+	t.Helper()
+
+	equal, err := Equal(got, expected)
+	if err != nil {
+		t.Fatalf("qbtest: %v", err)
+	}
+
+	if !equal {
+		t.Errorf("Expected\n%s\nbut got\n%s", normalized(expected), normalized(got))
+	}
+}
+
+// Golden Compare a query with testdata/<name>.influxql, rewritten with the
+// normalized query when Update is set
+func Golden(t testing.TB, name string, query string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".influxql")
+
+	if Update {
+		normal, err := Normalize(query)
+		if err != nil {
+			t.Fatalf("qbtest: %v", err)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("qbtest: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(normal+"\n"), 0644); err != nil {
+			t.Fatalf("qbtest: %v", err)
+		}
+
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("qbtest: %v, run the tests with -qbtest.update to create it", err)
+	}
+
+	AssertEqual(t, query, strings.TrimSpace(string(expected)))
+}
+
+// normalized Normalized query for messages, the query itself when it does
+// not parse
+func normalized(query string) string {
+	if normal, err := Normalize(query); err == nil {
+		return normal
+	}
+
+	return query
+}
+
+// semanticKey String equal for queries of the same meaning
+func semanticKey(query string) (string, error) {
+	q, err := qb.Parse(query)
+	if err != nil {
+		return "", err
+	}
+
+	spec := q.ToSpec()
+	criteria := render(groupsOf(spec.Criteria))
+
+	spec.Criteria = nil
+	sort.Strings(spec.GroupByTags)
+
+	for i, f := range spec.Fields {
+		spec.Fields[i] = strings.ReplaceAll(f, `"`, "")
+	}
+
+	if spec.Fill == "null" {
+		spec.Fill = nil
+	}
+
+	if strings.EqualFold(spec.Order, "ASC") {
+		spec.Order = ""
+	}
+
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b) + " WHERE " + criteria, nil
+}
+
+// atom A condition, or brackets of several AND groups
+type atom struct {
+	text   string
+	groups [][]atom
+}
+
+// groupsOf criteria as OR-ed groups of AND-ed atoms, following the rendered
+// order of the builder and the precedence of AND over OR
+func groupsOf(c *qb.CriteriaSpec) [][]atom {
+	if c == nil {
+		return nil
+	}
+
+	type term struct {
+		or   bool
+		atom atom
+	}
+
+	var terms []term
+
+	add := func(or bool, condition *qb.ConditionSpec, brackets *qb.CriteriaSpec) {
+		if condition != nil {
+			terms = append(terms, term{or, atom{text: conditionText(*condition)}})
+			return
+		}
+
+		groups := groupsOf(brackets)
+		if len(groups) == 0 {
+			return
+		}
+
+		terms = append(terms, term{or, atom{text: "(" + render(groups) + ")", groups: groups}})
+	}
+
+	add(false, c.Where, c.WhereBrackets)
+	for i := range c.And {
+		add(false, &c.And[i], nil)
+	}
+	for i := range c.Or {
+		add(true, &c.Or[i], nil)
+	}
+	for i := range c.AndBrackets {
+		add(false, nil, &c.AndBrackets[i])
+	}
+	for i := range c.OrBrackets {
+		add(true, nil, &c.OrBrackets[i])
+	}
+
+	var groups [][]atom
+
+	for _, t := range terms {
+		if t.or || groups == nil {
+			groups = append(groups, nil)
+		}
+
+		last := len(groups) - 1
+
+		// (a AND b) is a AND b inside an AND group
+		if len(t.atom.groups) == 1 {
+			groups[last] = append(groups[last], t.atom.groups[0]...)
+		} else {
+			groups[last] = append(groups[last], t.atom)
+		}
+	}
+
+	// A group of only (a OR b) is a OR b
+	var flat [][]atom
+	for _, g := range groups {
+		if len(g) == 1 && len(g[0].groups) > 1 {
+			flat = append(flat, g[0].groups...)
+		} else {
+			flat = append(flat, g)
+		}
+	}
+
+	return flat
+}
+
+// render renders groups with atoms and groups sorted, AND and OR commute
+func render(groups [][]atom) string {
+	texts := make([]string, 0, len(groups))
+
+	for _, g := range groups {
+		atoms := make([]string, len(g))
+		for i, a := range g {
+			atoms[i] = a.text
+		}
+		sort.Strings(atoms)
+
+		texts = append(texts, strings.Join(atoms, " AND "))
+	}
+	sort.Strings(texts)
+
+	return strings.Join(texts, " OR ")
+}
+
+func conditionText(c qb.ConditionSpec) string {
+	key := strings.ReplaceAll(c.Key, `"`, "")
+
+	op := c.Op
+	if op == "<>" {
+		op = string(qb.Neq)
+	}
+
+	switch {
+	case c.Var != "" && key == "":
+		return "$" + c.Var
+	case c.Var != "":
+		return fmt.Sprintf("%s %s $%s", key, op, c.Var)
+	case c.Regex != "":
+		return fmt.Sprintf("%s %s /%s/", key, op, c.Regex)
+	}
+
+	return fmt.Sprintf("%s %s %s", key, op, valueText(c.Value))
+}
+
+// valueText Text of a value, 1 and 1.0 are the same number
+func valueText(v interface{}) string {
+	switch n := v.(type) {
+	case int:
+		return strconv.FormatFloat(float64(n), 'g', -1, 64)
+	case int64:
+		return strconv.FormatFloat(float64(n), 'g', -1, 64)
+	case float64:
+		return strconv.FormatFloat(n, 'g', -1, 64)
+	case string:
+		return strconv.Quote(n)
+	}
+
+	return fmt.Sprint(v)
+}
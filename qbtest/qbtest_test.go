@@ -0,0 +1,144 @@
+package qbtest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+// update A package's own -update flag must not clash with qbtest's
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// fakeT testing.TB recording failures instead of failing
+type fakeT struct {
+	testing.TB
+	failures []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Fatalf(format string, args ...interface{}) {
+	f.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// failures runs a test function on a fakeT and returns its failures
+func failures(test func(t testing.TB)) []string {
+	fake := &fakeT{}
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		test(fake)
+	}()
+	<-done
+
+	return fake.failures
+}
+
+func TestNormalize(t *testing.T) {
+	got, err := Normalize(`select  value from cpu   where host='a'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `SELECT "value" FROM "cpu" WHERE "host" = 'a'`; got != expected {
+		t.Errorf("Expected %s but got %s", expected, got)
+	}
+
+	if _, err := Normalize("SELECT FROM"); err == nil {
+		t.Error("Expected a parse error")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	for _, c := range []struct {
+		a, b     string
+		expected bool
+	}{
+		{`SELECT "value" FROM "cpu"`, `select value from cpu`, true},
+		{`SELECT value FROM cpu WHERE host = 'a' AND region = 'eu'`, `SELECT value FROM cpu WHERE region = 'eu' AND host = 'a'`, true},
+		{`SELECT value FROM cpu WHERE host = 'a' OR host = 'b'`, `SELECT value FROM cpu WHERE host = 'b' OR host = 'a'`, true},
+		{`SELECT value FROM cpu WHERE a = 1 AND b = 2 OR c = 3`, `SELECT value FROM cpu WHERE c = 3 OR b = 2 AND a = 1`, true},
+		{`SELECT value FROM cpu WHERE (a = 1 AND b = 2) AND c = 3`, `SELECT value FROM cpu WHERE a = 1 AND (c = 3 AND b = 2)`, true},
+		{`SELECT value FROM cpu WHERE (a = 1 OR b = 2)`, `SELECT value FROM cpu WHERE b = 2 OR a = 1`, true},
+		{`SELECT value FROM cpu WHERE a = 1 AND (b = 2 OR c = 3)`, `SELECT value FROM cpu WHERE (c = 3 OR b = 2) AND a = 1`, true},
+		{`SELECT value FROM cpu WHERE a = 1`, `SELECT value FROM cpu WHERE a = 1.0`, true},
+		{`SELECT value FROM cpu WHERE a <> 1`, `SELECT value FROM cpu WHERE a != 1`, true},
+		{`SELECT MEAN(value) FROM cpu GROUP BY host, time(1m), region fill(null) ORDER BY time ASC`, `SELECT MEAN("value") FROM "cpu" GROUP BY time(1m), "region", "host"`, true},
+		{`SELECT value FROM cpu WHERE a = 1 AND (b = 2 OR c = 3)`, `SELECT value FROM cpu WHERE a = 1 AND b = 2 OR c = 3`, false},
+		{`SELECT value FROM cpu WHERE a = '1'`, `SELECT value FROM cpu WHERE a = 1`, false},
+		{`SELECT a, b FROM cpu`, `SELECT b, a FROM cpu`, false},
+		{`SELECT value FROM cpu LIMIT 1`, `SELECT value FROM cpu LIMIT 2`, false},
+	} {
+		equal, err := Equal(c.a, c.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if equal != c.expected {
+			t.Errorf("Expected %v for %s and %s", c.expected, c.a, c.b)
+		}
+	}
+
+	if _, err := Equal("SELECT FROM", "SELECT value FROM cpu"); err == nil {
+		t.Error("Expected a parse error")
+	}
+}
+
+func TestAssertEqual(t *testing.T) {
+	builder := qb.New().Select("value").From("cpu").WhereEq("host", "a").AndEq("region", "eu")
+
+	AssertEqual(t, builder.Build(), `SELECT value FROM cpu WHERE region = 'eu' AND host = 'a'`)
+
+	if f := failures(func(t testing.TB) {
+		AssertEqual(t, builder.Build(), `SELECT value FROM cpu WHERE host = 'b'`)
+	}); len(f) != 1 {
+		t.Errorf("Expected a failure but got %v", f)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	Golden(t, "cpu", qb.New().Select("value").From("cpu").WhereEq("host", "a").Build())
+
+	for _, name := range []string{"cpu", "missing"} {
+		if f := failures(func(t testing.TB) {
+			Golden(t, name, qb.New().Select("value").From("mem").Build())
+		}); len(f) != 1 {
+			t.Errorf("Expected a failure for %s but got %v", name, f)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	Update = true
+	defer func() { Update = false }()
+
+	Golden(t, "nested/mem", `select value from mem`)
+
+	b, err := os.ReadFile(filepath.Join("testdata", "nested", "mem.influxql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "SELECT \"value\" FROM \"mem\"\n"; string(b) != expected {
+		t.Errorf("Expected %q but got %q", expected, b)
+	}
+}
@@ -0,0 +1,55 @@
+package qbtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+// Recorder Executor recording the queries it runs, safe for concurrent use
+type Recorder struct {
+	// Executor Optional backend, empty responses are returned without one
+	Executor qb.Executor
+
+	mu      sync.Mutex
+	queries []string
+}
+
+// Execute Record the query and run it on the backend
+func (r *Recorder) Execute(ctx context.Context, query string) (*qb.Response, error) {
+	r.mu.Lock()
+	r.queries = append(r.queries, query)
+	r.mu.Unlock()
+
+	if r.Executor == nil {
+		return &qb.Response{Results: []qb.Result{}}, nil
+	}
+
+	return r.Executor.Execute(ctx, query)
+}
+
+// Queries Queries recorded so far, in order
+func (r *Recorder) Queries() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.queries...)
+}
+
+// AssertQueries Fail the test unless the recorded queries have the meaning of
+// the expected ones, in order
+func (r *Recorder) AssertQueries(t testing.TB, expected ...string) {
+	t.Helper()
+
+	queries := r.Queries()
+	if len(queries) != len(expected) {
+		t.Errorf("Expected %d queries but got %d: %q", len(expected), len(queries), queries)
+		return
+	}
+
+	for i, q := range queries {
+		AssertEqual(t, q, expected[i])
+	}
+}
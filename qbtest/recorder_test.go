@@ -0,0 +1,41 @@
+package qbtest
+
+import (
+	"context"
+	"testing"
+
+	qb "github.com/benjamin658/influx-query-builder"
+)
+
+type fakeExecutor struct{}
+
+func (fakeExecutor) Execute(ctx context.Context, query string) (*qb.Response, error) {
+	return &qb.Response{Results: []qb.Result{{Series: []qb.Series{{Name: "cpu"}}}}}, nil
+}
+
+func TestRecorder(t *testing.T) {
+	recorder := &Recorder{}
+
+	resp, err := recorder.Execute(context.Background(), `SELECT "value" FROM "cpu"`)
+	if err != nil || len(resp.Results) != 0 {
+		t.Errorf("Unexpected %+v %v", resp, err)
+	}
+
+	recorder.Executor = fakeExecutor{}
+
+	resp, err = recorder.Execute(context.Background(), `SELECT "value" FROM "mem"`)
+	if err != nil || resp.Results[0].Series[0].Name != "cpu" {
+		t.Errorf("Unexpected %+v %v", resp, err)
+	}
+
+	recorder.AssertQueries(t, `select value from cpu`, `select value from mem`)
+
+	for _, expected := range [][]string{
+		{`select value from cpu`},
+		{`select value from cpu`, `select value from disk`},
+	} {
+		if f := failures(func(t testing.TB) { recorder.AssertQueries(t, expected...) }); len(f) != 1 {
+			t.Errorf("Expected a failure for %q but got %v", expected, f)
+		}
+	}
+}
@@ -2,9 +2,11 @@ package influxquerybuilder
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // Duration Duration interface
@@ -17,7 +19,11 @@ type Duration interface {
 	Hour(uint) Duration
 	Day(uint) Duration
 	Week(uint) Duration
+	Infinite() Duration
 	getDuration() string
+	getLiteral() string
+	isSet() bool
+	isInfinite() bool
 }
 
 // DurationType DurationType struct
@@ -95,13 +101,39 @@ func (t *DurationType) Week(d uint) Duration {
 	return t
 }
 
+// Infinite INF, only valid as a retention policy duration
+func (t *DurationType) Infinite() Duration {
+	t.unit = "INF"
+	t.value = 0
+
+	return t
+}
+
 func (t *DurationType) getDuration() string {
-	return fmt.Sprintf("time(%d%s)", t.value, t.unit)
+	return fmt.Sprintf("time(%s)", t.getLiteral())
+}
+
+func (t *DurationType) getLiteral() string {
+	if t.isInfinite() {
+		return t.unit
+	}
+
+	return fmt.Sprintf("%d%s", t.value, t.unit)
+}
+
+func (t *DurationType) isSet() bool {
+	return t.isInfinite() || (t.unit != "" && t.value > 0)
+}
+
+func (t *DurationType) isInfinite() bool {
+	return t.unit == "INF"
 }
 
 // QueryBuilder QueryBuilder interface
 type QueryBuilder interface {
 	Select(fields ...string) QueryBuilder
+	Into(string) QueryBuilder
+	IntoRP(string, string) QueryBuilder
 	From(string) QueryBuilder
 	FromRP(string, string) QueryBuilder
 	Where(string, string, interface{}) QueryBuilder
@@ -110,18 +142,35 @@ type QueryBuilder interface {
 	WhereBrackets(QueryBuilder) QueryBuilder
 	AndBrackets(QueryBuilder) QueryBuilder
 	OrBrackets(QueryBuilder) QueryBuilder
+	WhereEq(string, interface{}) QueryBuilder
+	AndEq(string, interface{}) QueryBuilder
+	OrEq(string, interface{}) QueryBuilder
+	WhereIn(string, ...interface{}) QueryBuilder
+	AndIn(string, ...interface{}) QueryBuilder
+	OrIn(string, ...interface{}) QueryBuilder
+	WhereVar(Variable) QueryBuilder
+	AndVar(Variable) QueryBuilder
+	OrVar(Variable) QueryBuilder
 	// Deprecated: Use GroupByTime instead
 	GroupBy(string) QueryBuilder
 	GroupByTime(Duration) QueryBuilder
 	GroupByTag(...string) QueryBuilder
+	AutoGroupByTime(time.Time, time.Time, int, time.Duration) QueryBuilder
 	Fill(interface{}) QueryBuilder
 	Limit(uint) QueryBuilder
 	Offset(uint) QueryBuilder
 	Desc() QueryBuilder
 	Asc() QueryBuilder
 	Build() string
+	BuildPretty(PrettyOptions) string
+	BuildFlux(string) (string, error)
+	BuildSQL() (string, error)
+	Validate() error
+	ValidateAgainst(*Schema) error
 	Clean() QueryBuilder
 	GetQueryStruct() CurrentQuery
+	ToSpec() QuerySpec
+	FromSpec(QuerySpec) (QueryBuilder, error)
 }
 
 // Tag Tag struct
@@ -149,8 +198,10 @@ type Query struct {
 	offset        uint
 	_offset       bool
 	fill          interface{}
+	into          string
 
-	retentionPolicy string
+	retentionPolicy     string
+	intoRetentionPolicy string
 }
 
 // CurrentQuery Get current query
@@ -163,15 +214,34 @@ type CurrentQuery struct {
 	AndBrackets   []QueryBuilder
 	OrBrackets    []QueryBuilder
 	Fields        []string
+	Into          string
 	GroupBy       string
 	GroupByTime   string
 	GroupByTag    string
+	GroupByTags   []string
+	Fill          interface{}
 	Limit         uint
 	Offset        uint
 	Order         string
 	IsLimitSet    bool
 	IsOffsetSet   bool
-}
+
+	RetentionPolicy     string
+	IntoRetentionPolicy string
+}
+
+var (
+	// ErrMissingFields SELECT without fields
+	ErrMissingFields = errors.New("influxquerybuilder: query needs at least one field")
+	// ErrMissingMeasurement SELECT without FROM
+	ErrMissingMeasurement = errors.New("influxquerybuilder: query needs a measurement")
+	// ErrRegexOperator Regex value without =~ or !~, or the other way round
+	ErrRegexOperator = errors.New("influxquerybuilder: regex values must be used with =~ or !~")
+	// ErrInvalidOperator Operator is not part of the InfluxQL grammar
+	ErrInvalidOperator = errors.New("influxquerybuilder: invalid operator")
+	// ErrEmptyBrackets Brackets without criteria, e.g. WhereIn without values
+	ErrEmptyBrackets = errors.New("influxquerybuilder: brackets need at least one criteria")
+)
 
 // New New QueryBuilder
 func New() QueryBuilder {
@@ -189,6 +259,19 @@ func (q *Query) Select(fields ...string) QueryBuilder {
 	return q
 }
 
+// Into INTO measurement
+func (q *Query) Into(measurement string) QueryBuilder {
+	q.into = measurement
+	return q
+}
+
+// IntoRP INTO retention policy qualified measurement
+func (q *Query) IntoRP(retentionPolicy, measurement string) QueryBuilder {
+	q.intoRetentionPolicy = retentionPolicy
+	q.into = measurement
+	return q
+}
+
 // FromRP retention policy qualified measurement
 func (q *Query) FromRP(retentionPolicy, measurement string) QueryBuilder {
 	q.retentionPolicy = retentionPolicy
@@ -245,7 +328,7 @@ func (q *Query) GroupBy(time string) QueryBuilder {
 	return q
 }
 
-// GroupByTime GROUP BY time
+// GroupByTime GROUP BY time, INF is not rendered and fails Validate
 func (q *Query) GroupByTime(duration Duration) QueryBuilder {
 	q.groupByTime = duration.getDuration()
 	return q
@@ -300,12 +383,20 @@ func (q *Query) GetQueryStruct() CurrentQuery {
 		AndBrackets:   q.andBrackets,
 		OrBrackets:    q.orBrackets,
 		Fields:        q.fields,
+		Into:          q.into,
 		GroupBy:       q.groupByTime,
+		GroupByTime:   q.groupByTime,
+		GroupByTag:    strings.Join(q.groupByTags, ","),
+		GroupByTags:   q.groupByTags,
+		Fill:          q.fill,
 		Limit:         q.limit,
 		Offset:        q.offset,
 		Order:         q.order,
 		IsLimitSet:    q._limit,
 		IsOffsetSet:   q._offset,
+
+		RetentionPolicy:     q.retentionPolicy,
+		IntoRetentionPolicy: q.intoRetentionPolicy,
 	}
 }
 
@@ -314,6 +405,7 @@ func (q *Query) Build() string {
 	var buffer bytes.Buffer
 
 	buffer.WriteString(q.buildFields())
+	buffer.WriteString(q.buildInto())
 	buffer.WriteString(q.buildFrom())
 	buffer.WriteString(q.buildWhere())
 	buffer.WriteString(q.buildGroupBy())
@@ -325,6 +417,91 @@ func (q *Query) Build() string {
 	return strings.TrimSpace(buffer.String())
 }
 
+// Validate Validate the query, so it can be used as a Statement
+func (q *Query) Validate() error {
+	if len(q.fields) == 0 {
+		return ErrMissingFields
+	}
+
+	if q.measurement == "" {
+		return ErrMissingMeasurement
+	}
+
+	if q.groupByTime == infiniteGroupByTime {
+		return fmt.Errorf("group by time: INF is not allowed: %w", ErrInvalidDuration)
+	}
+
+	return q.validateCriteria()
+}
+
+// validateCriteria validates the criteria of the query and its brackets
+func (q *Query) validateCriteria() error {
+	tags := append([]Tag{}, q.and...)
+	tags = append(tags, q.or...)
+	if q.where != (Tag{}) {
+		tags = append(tags, q.where)
+	}
+
+	for _, tag := range tags {
+		if err := validateTag(tag); err != nil {
+			return err
+		}
+	}
+
+	brackets := append([]QueryBuilder{}, q.andBrackets...)
+	brackets = append(brackets, q.orBrackets...)
+	if q.whereBrackets != nil {
+		brackets = append(brackets, q.whereBrackets)
+	}
+
+	for _, b := range brackets {
+		if bq, ok := b.(*Query); ok {
+			if bq.where == (Tag{}) && bq.whereBrackets == nil {
+				return ErrEmptyBrackets
+			}
+
+			if err := bq.validateCriteria(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasCriteria whether brackets render any criteria. Brackets without
+// criteria are left out of the query, Validate reports them
+func hasCriteria(b QueryBuilder) bool {
+	if b == nil {
+		return false
+	}
+
+	c := b.GetQueryStruct()
+
+	return c.Where != (Tag{}) || hasCriteria(c.WhereBrackets)
+}
+
+func validateTag(tag Tag) error {
+	_, isVariable := tag.value.(Variable)
+	if isVariable && tag.key == "" && tag.op == "" {
+		return nil
+	}
+
+	op := Operator(tag.op)
+	if !op.Valid() {
+		return fmt.Errorf("%s %s: %w", tag.key, tag.op, ErrInvalidOperator)
+	}
+
+	_, isRegex := tag.value.(*regexp.Regexp)
+	regexOp := op.IsRegex()
+
+	if isRegex != regexOp && !(isVariable && regexOp) {
+		return fmt.Errorf("%s %s: %w", tag.key, tag.op, ErrRegexOperator)
+	}
+
+	return nil
+}
+
 var functionMatcher = regexp.MustCompile(`.+\(.+\)$`)
 
 func (q *Query) buildFields() string {
@@ -350,26 +527,40 @@ func (q *Query) buildFields() string {
 		if functionMatcher.MatchString(selectField) {
 			fields[i] = selectField
 		} else {
-			fields[i] = fmt.Sprintf("\"%s\"", selectField)
+			fields[i] = quoteKey(splitCast(selectField))
 		}
 
 		if selectAs != "" {
-			fields[i] = fields[i] + " AS " + fmt.Sprintf("\"%s\"", selectAs)
+			fields[i] = fields[i] + " AS " + quoteIdent(selectAs)
 		}
 	}
 
 	return fmt.Sprintf("SELECT %s ", strings.Join(fields, ","))
 }
 
+func (q *Query) buildInto() string {
+	if q.into == "" {
+		return ""
+	}
+	name := ""
+	if q.intoRetentionPolicy != "" {
+		name = q.intoRetentionPolicy + "." + quoteIdent(q.into)
+	} else {
+		name = quoteIdent(q.into)
+	}
+
+	return fmt.Sprintf(`INTO %s `, name)
+}
+
 func (q *Query) buildFrom() string {
 	if q.measurement == "" {
 		return ""
 	}
 	name := ""
 	if q.retentionPolicy != "" {
-		name = fmt.Sprintf(`%s."%s"`, q.retentionPolicy, q.measurement)
+		name = q.retentionPolicy + "." + quoteIdent(q.measurement)
 	} else {
-		name = fmt.Sprintf(`"%s"`, q.measurement)
+		name = quoteIdent(q.measurement)
 	}
 
 	return fmt.Sprintf(`FROM %s `, name)
@@ -381,13 +572,13 @@ func (q *Query) buildWhere() string {
 	andCriteria := make([]string, 0)
 	orCriteria := make([]string, 0)
 
-	if q.where != (Tag{}) || q.whereBrackets != nil {
+	if q.where != (Tag{}) || hasCriteria(q.whereBrackets) {
 		if q.where != (Tag{}) {
 			buffer.WriteString("WHERE ")
 			whereCriteria = getCriteriaTemplate(q.where)
 			buffer.WriteString(whereCriteria)
 			buffer.WriteString(" ")
-		} else if q.whereBrackets != nil {
+		} else {
 			buffer.WriteString("WHERE (")
 			buffer.WriteString(strings.Replace(q.whereBrackets.Build(), "WHERE ", "", 1))
 			buffer.WriteString(") ")
@@ -419,6 +610,10 @@ func (q *Query) buildWhere() string {
 
 		if q.andBrackets != nil {
 			for _, g := range q.andBrackets {
+				if !hasCriteria(g) {
+					continue
+				}
+
 				buffer.WriteString("AND (")
 				buffer.WriteString(strings.Replace(g.Build(), "WHERE ", "", 1))
 				buffer.WriteString(") ")
@@ -427,6 +622,10 @@ func (q *Query) buildWhere() string {
 
 		if q.orBrackets != nil {
 			for _, g := range q.orBrackets {
+				if !hasCriteria(g) {
+					continue
+				}
+
 				buffer.WriteString("OR (")
 				buffer.WriteString(strings.Replace(g.Build(), "WHERE ", "", 1))
 				buffer.WriteString(") ")
@@ -437,13 +636,21 @@ func (q *Query) buildWhere() string {
 	return buffer.String()
 }
 
+// infiniteGroupByTime GROUP BY time of an INF duration, which InfluxQL rejects
+const infiniteGroupByTime = "time(INF)"
+
 func (q *Query) buildGroupBy() string {
-	if q.groupByTime == "" && len(q.groupByTags) == 0 {
+	groupByTime := q.groupByTime
+	if groupByTime == infiniteGroupByTime {
+		groupByTime = ""
+	}
+
+	if groupByTime == "" && len(q.groupByTags) == 0 {
 		return ""
 	}
 	var buffer bytes.Buffer
-	if q.groupByTime != "" {
-		buffer.WriteString(q.groupByTime)
+	if groupByTime != "" {
+		buffer.WriteString(groupByTime)
 	}
 	if len(q.groupByTags) > 0 {
 		if buffer.Len() > 0 {
@@ -513,14 +720,49 @@ func (q *Query) buildOffset() string {
 }
 
 func getCriteriaTemplate(tag Tag) string {
-	switch tag.value.(type) {
+	name, cast := splitCast(tag.key)
+	key := quoteKey(name, cast)
+
+	if v, ok := tag.value.(*regexp.Regexp); ok {
+		return fmt.Sprintf(`%s %s /%s/`, key, tag.op, escapeRegex(v.String()))
+	}
+
+	if v, ok := tag.value.(Variable); ok {
+		return variableCriteria(key, tag, v)
+	}
+
+	// Tags are always compared as strings
+	if cast == TagType || cast == StringType {
+		return fmt.Sprintf(`%s %s %s`, key, tag.op, quoteString(fmt.Sprint(tag.value)))
+	}
+
+	switch v := tag.value.(type) {
+	case time.Time:
+		return fmt.Sprintf(`%s %s %s`, key, tag.op, quoteString(v.UTC().Format(time.RFC3339Nano)))
 	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-		return fmt.Sprintf(`"%s" %s %d`, tag.key, tag.op, tag.value)
+		return fmt.Sprintf(`%s %s %d`, key, tag.op, tag.value)
 	case float32, float64:
-		return fmt.Sprintf(`"%s" %s %g`, tag.key, tag.op, tag.value)
+		return fmt.Sprintf(`%s %s %g`, key, tag.op, tag.value)
 	case bool:
-		return fmt.Sprintf(`"%s" %s %t`, tag.key, tag.op, tag.value)
+		return fmt.Sprintf(`%s %s %t`, key, tag.op, tag.value)
 	default:
-		return fmt.Sprintf(`"%s" %s '%s'`, tag.key, tag.op, tag.value)
+		return fmt.Sprintf(`%s %s %s`, key, tag.op, quoteString(fmt.Sprintf("%s", tag.value)))
 	}
 }
+
+// escapeRegex escapes the unescaped slashes of a regex literal
+func escapeRegex(pattern string) string {
+	var buffer bytes.Buffer
+	escaped := false
+
+	for _, r := range pattern {
+		if r == '/' && !escaped {
+			buffer.WriteRune('\\')
+		}
+
+		escaped = r == '\\' && !escaped
+		buffer.WriteRune(r)
+	}
+
+	return buffer.String()
+}
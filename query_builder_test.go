@@ -1,7 +1,9 @@
 package influxquerybuilder
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
 	"testing"
 )
 
@@ -100,6 +102,28 @@ func TestFrom(t *testing.T) {
 	assert(t, q, expected)
 }
 
+func TestInto(t *testing.T) {
+	expected := `SELECT MEAN("temperature") INTO "temperature_1h" FROM "measurement"`
+	builder := New()
+	q := builder.
+		Select(`MEAN("temperature")`).
+		Into("temperature_1h").
+		From("measurement").
+		Build()
+
+	assert(t, q, expected)
+
+	expected = `SELECT MEAN("temperature") INTO rp_1y."temperature_1h" FROM "measurement"`
+	q = builder.
+		Clean().
+		Select(`MEAN("temperature")`).
+		IntoRP("rp_1y", "temperature_1h").
+		From("measurement").
+		Build()
+
+	assert(t, q, expected)
+}
+
 func TestWhere(t *testing.T) {
 	expected := `SELECT "temperature","humidity" FROM "measurement" WHERE "time" < '2018-11-02T09:35:25Z'`
 	builder := New()
@@ -267,6 +291,16 @@ func TestGroupByTimeWeek(t *testing.T) {
 	assert(t, q, expected)
 }
 
+func TestGroupByTimeInfinite(t *testing.T) {
+	builder := New().
+		Select("temperature").
+		From("measurement").
+		GroupByTime(NewDuration().Infinite())
+
+	assert(t, builder.Build(), `SELECT "temperature" FROM "measurement"`)
+	assert(t, errors.Is(builder.Validate(), ErrInvalidDuration), true)
+}
+
 func TestGroupByTag(t *testing.T) {
 	expected := `SELECT "temperature","humidity" FROM "measurement" GROUP BY sensorId`
 	builder := New()
@@ -449,4 +483,55 @@ func TestGetQueryStruct(t *testing.T) {
 	assert(t, q.Offset, expected)
 	assert(t, q.IsOffsetSet, true)
 	assert(t, q.Order, "ASC")
+
+	q = New().
+		Select("temperature").
+		FromRP("rp_1h", "measurement").
+		GroupByTime(NewDuration().Minute(10)).
+		GroupByTag("sensorId", "location").
+		Fill("none").
+		GetQueryStruct()
+
+	assert(t, q.RetentionPolicy, "rp_1h")
+	assert(t, q.GroupByTime, "time(10m)")
+	assert(t, q.GroupByTag, "sensorId,location")
+	assert(t, q.GroupByTags[1], "location")
+	assert(t, q.Fill, "none")
+}
+
+func TestValidate(t *testing.T) {
+	assert(t, New().From("measurement").Validate(), ErrMissingFields)
+	assert(t, New().Select("temperature").Validate(), ErrMissingMeasurement)
+	assert(t, New().Select("temperature").From("measurement").Validate(), nil)
+}
+
+func TestWhereRegex(t *testing.T) {
+	expected := `SELECT "temperature" FROM "measurement" WHERE "host" =~ /^web-\d+/ AND "path" !~ /^\/api\/v1/`
+	builder := New()
+	q := builder.
+		Select("temperature").
+		From("measurement").
+		Where("host", "=~", regexp.MustCompile(`^web-\d+`)).
+		And("path", "!~", regexp.MustCompile(`^/api\/v1`)).
+		Build()
+
+	assert(t, q, expected)
+	assert(t, builder.Validate(), nil)
+}
+
+func TestWhereRegexValidate(t *testing.T) {
+	err := New().
+		Select("temperature").
+		From("measurement").
+		Where("host", "=", regexp.MustCompile(`^web`)).
+		Validate()
+	assert(t, errors.Is(err, ErrRegexOperator), true)
+
+	err = New().
+		Select("temperature").
+		From("measurement").
+		Where("time", ">", "2018-11-01T06:33:57.503Z").
+		AndBrackets(New().Where("host", "=~", "/^web/")).
+		Validate()
+	assert(t, errors.Is(err, ErrRegexOperator), true)
 }
@@ -0,0 +1,67 @@
+package influxquerybuilder
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrEmptyResponse An Executor returned neither a response nor an error
+var ErrEmptyResponse = errors.New("influxquerybuilder: empty response")
+
+// Executor Executor interface, runs InfluxQL and returns the decoded response
+type Executor interface {
+	Execute(ctx context.Context, query string) (*Response, error)
+}
+
+// Response Response of the /query endpoint
+type Response struct {
+	Results []Result `json:"results"`
+	Err     string   `json:"error,omitempty"`
+}
+
+// Result Result of a single statement
+type Result struct {
+	StatementID int       `json:"statement_id"`
+	Series      []Series  `json:"series,omitempty"`
+	Messages    []Message `json:"messages,omitempty"`
+	Partial     bool      `json:"partial,omitempty"`
+	Err         string    `json:"error,omitempty"`
+}
+
+// Series Series of a result
+type Series struct {
+	Name    string            `json:"name"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	Columns []string          `json:"columns"`
+	Values  [][]interface{}   `json:"values,omitempty"`
+	Partial bool              `json:"partial,omitempty"`
+}
+
+// Message Informational message of a result
+type Message struct {
+	Level string `json:"level"`
+	Text  string `json:"text"`
+}
+
+// Error Request level error, nil if the request succeeded, ErrEmptyResponse
+// for a nil response
+func (r *Response) Error() error {
+	if r == nil {
+		return ErrEmptyResponse
+	}
+
+	if r.Err != "" {
+		return errors.New(r.Err)
+	}
+
+	return nil
+}
+
+// Error Statement level error, nil if the statement succeeded
+func (r Result) Error() error {
+	if r.Err != "" {
+		return errors.New(r.Err)
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package influxquerybuilder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrNoRetentionPolicyChange ALTER RETENTION POLICY without any option
+var ErrNoRetentionPolicyChange = errors.New("influxquerybuilder: alter retention policy needs at least one option")
+
+// RetentionPolicyBuilder RetentionPolicyBuilder interface
+type RetentionPolicyBuilder interface {
+	Duration(Duration) RetentionPolicyBuilder
+	Replication(uint) RetentionPolicyBuilder
+	ShardDuration(Duration) RetentionPolicyBuilder
+	Default() RetentionPolicyBuilder
+	Statement
+}
+
+// RetentionPolicy RetentionPolicy struct
+type RetentionPolicy struct {
+	alter         bool
+	name          string
+	database      string
+	duration      Duration
+	replication   uint
+	_replication  bool
+	shardDuration Duration
+	_default      bool
+}
+
+// CreateRetentionPolicy CREATE RETENTION POLICY "name" ON "database"
+func CreateRetentionPolicy(name, database string) RetentionPolicyBuilder {
+	return &RetentionPolicy{name: name, database: database}
+}
+
+// AlterRetentionPolicy ALTER RETENTION POLICY "name" ON "database"
+func AlterRetentionPolicy(name, database string) RetentionPolicyBuilder {
+	return &RetentionPolicy{alter: true, name: name, database: database}
+}
+
+// DropRetentionPolicy DROP RETENTION POLICY "name" ON "database"
+func DropRetentionPolicy(name, database string) Statement {
+	return &dropStatement{kind: "RETENTION POLICY", name: name, database: database, on: true}
+}
+
+// Duration DURATION x, INF is allowed
+func (r *RetentionPolicy) Duration(duration Duration) RetentionPolicyBuilder {
+	r.duration = duration
+	return r
+}
+
+// Replication REPLICATION x
+func (r *RetentionPolicy) Replication(n uint) RetentionPolicyBuilder {
+	r._replication = true
+	r.replication = n
+	return r
+}
+
+// ShardDuration SHARD DURATION x
+func (r *RetentionPolicy) ShardDuration(duration Duration) RetentionPolicyBuilder {
+	r.shardDuration = duration
+	return r
+}
+
+// Default DEFAULT
+func (r *RetentionPolicy) Default() RetentionPolicyBuilder {
+	r._default = true
+	return r
+}
+
+// Validate Validate the statement
+func (r *RetentionPolicy) Validate() error {
+	if err := validateName("retention policy", r.name); err != nil {
+		return err
+	}
+
+	if err := validateName("database", r.database); err != nil {
+		return err
+	}
+
+	if r.alter {
+		if r.duration == nil && !r._replication && r.shardDuration == nil && !r._default {
+			return ErrNoRetentionPolicyChange
+		}
+	} else {
+		// CREATE requires both DURATION and REPLICATION
+		if r.duration == nil {
+			return fmt.Errorf("duration: %w", ErrInvalidDuration)
+		}
+
+		if !r._replication {
+			return ErrInvalidReplication
+		}
+	}
+
+	if r.duration != nil {
+		if err := validateDuration("duration", r.duration, true); err != nil {
+			return err
+		}
+	}
+
+	if r._replication && r.replication < 1 {
+		return ErrInvalidReplication
+	}
+
+	if r.shardDuration != nil {
+		if err := validateDuration("shard duration", r.shardDuration, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Build Build statement string, empty if the statement is invalid
+func (r *RetentionPolicy) Build() string {
+	if r.Validate() != nil {
+		return ""
+	}
+
+	var buffer bytes.Buffer
+
+	verb := "CREATE"
+	if r.alter {
+		verb = "ALTER"
+	}
+
+	buffer.WriteString(fmt.Sprintf(
+		"%s RETENTION POLICY %s ON %s",
+		verb,
+		quoteIdent(r.name),
+		quoteIdent(r.database),
+	))
+
+	if r.duration != nil {
+		buffer.WriteString(fmt.Sprintf(" DURATION %s", r.duration.getLiteral()))
+	}
+
+	if r._replication {
+		buffer.WriteString(fmt.Sprintf(" REPLICATION %d", r.replication))
+	}
+
+	if r.shardDuration != nil {
+		buffer.WriteString(fmt.Sprintf(" SHARD DURATION %s", r.shardDuration.getLiteral()))
+	}
+
+	if r._default {
+		buffer.WriteString(" DEFAULT")
+	}
+
+	return buffer.String()
+}
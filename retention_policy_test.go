@@ -0,0 +1,67 @@
+package influxquerybuilder
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreateRetentionPolicy(t *testing.T) {
+	expected := `CREATE RETENTION POLICY "one_week" ON "telemetry" DURATION 1w REPLICATION 1 SHARD DURATION 1d DEFAULT`
+	q := CreateRetentionPolicy("one_week", "telemetry").
+		Duration(NewDuration().Week(1)).
+		Replication(1).
+		ShardDuration(NewDuration().Day(1)).
+		Default().
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestCreateRetentionPolicyInfinite(t *testing.T) {
+	expected := `CREATE RETENTION POLICY "forever" ON "telemetry" DURATION INF REPLICATION 2`
+	q := CreateRetentionPolicy("forever", "telemetry").
+		Duration(NewDuration().Infinite()).
+		Replication(2).
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestCreateRetentionPolicyValidate(t *testing.T) {
+	err := CreateRetentionPolicy("rp", "telemetry").Replication(1).Validate()
+	assert(t, errors.Is(err, ErrInvalidDuration), true)
+
+	err = CreateRetentionPolicy("rp", "telemetry").Duration(NewDuration().Day(1)).Validate()
+	assert(t, errors.Is(err, ErrInvalidReplication), true)
+
+	err = CreateRetentionPolicy("rp", "").Duration(NewDuration().Day(1)).Replication(1).Validate()
+	assert(t, errors.Is(err, ErrEmptyName), true)
+
+	err = CreateRetentionPolicy("rp", "telemetry").
+		Duration(NewDuration().Day(1)).
+		Replication(1).
+		ShardDuration(NewDuration().Infinite()).
+		Validate()
+	assert(t, errors.Is(err, ErrInvalidDuration), true)
+}
+
+func TestAlterRetentionPolicy(t *testing.T) {
+	expected := `ALTER RETENTION POLICY "one_week" ON "telemetry" DURATION 2w DEFAULT`
+	q := AlterRetentionPolicy("one_week", "telemetry").
+		Duration(NewDuration().Week(2)).
+		Default().
+		Build()
+
+	assert(t, q, expected)
+
+	err := AlterRetentionPolicy("one_week", "telemetry").Validate()
+	assert(t, errors.Is(err, ErrNoRetentionPolicyChange), true)
+}
+
+func TestDropRetentionPolicy(t *testing.T) {
+	expected := `DROP RETENTION POLICY "one_week" ON "telemetry"`
+	q := DropRetentionPolicy("one_week", "telemetry").Build()
+
+	assert(t, q, expected)
+	assert(t, errors.Is(DropRetentionPolicy("one_week", "").Validate(), ErrEmptyName), true)
+}
@@ -0,0 +1,352 @@
+package influxquerybuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Schema Known measurements, decodable from JSON or YAML
+type Schema struct {
+	Measurements map[string]*MeasurementSchema `json:"measurements" yaml:"measurements"`
+}
+
+// MeasurementSchema Tag keys and field keys with their types
+type MeasurementSchema struct {
+	Tags   []string           `json:"tags" yaml:"tags"`
+	Fields map[string]KeyType `json:"fields" yaml:"fields"`
+}
+
+// ErrMissingSchema ValidateAgainst without a schema
+var ErrMissingSchema = errors.New("influxquerybuilder: schema is nil")
+
+// SchemaError A single schema violation
+type SchemaError struct {
+	Key     string
+	Message string
+}
+
+// SchemaErrors Every schema violation of a query
+type SchemaErrors []SchemaError
+
+// Error Error interface
+func (e SchemaErrors) Error() string {
+	messages := make([]string, len(e))
+
+	for i, err := range e {
+		messages[i] = fmt.Sprintf("%s: %s", err.Key, err.Message)
+	}
+
+	return "influxquerybuilder: " + strings.Join(messages, "; ")
+}
+
+// NewSchema New Schema
+func NewSchema() *Schema {
+	return &Schema{Measurements: map[string]*MeasurementSchema{}}
+}
+
+// LoadSchema Load a JSON schema
+func LoadSchema(r io.Reader) (*Schema, error) {
+	s := NewSchema()
+
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// LoadSchemaYAML Load a YAML schema, in the layout of the JSON one
+func LoadSchemaYAML(r io.Reader) (*Schema, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewSchema()
+
+	if err := unmarshalYAML(data, s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// LoadSchemaFile Load a schema file, YAML for .yaml and .yml, JSON otherwise
+func LoadSchemaFile(path string) (*Schema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return LoadSchemaYAML(f)
+	}
+
+	return LoadSchema(f)
+}
+
+// AddMeasurement Add or extend a measurement
+func (s *Schema) AddMeasurement(name string, tags []string, fields map[string]KeyType) *Schema {
+	m := s.measurement(name)
+	m.Tags = append(m.Tags, tags...)
+
+	for k, t := range fields {
+		m.Fields[k] = t
+	}
+
+	return s
+}
+
+// LoadFieldKeys Load the output of SHOW FIELD KEYS
+func (s *Schema) LoadFieldKeys(resp *Response) error {
+	return s.load(resp, func(m *MeasurementSchema, row map[string]interface{}) {
+		key, _ := row["fieldKey"].(string)
+		t, _ := row["fieldType"].(string)
+		m.Fields[key] = KeyType(t)
+	})
+}
+
+// LoadTagKeys Load the output of SHOW TAG KEYS
+func (s *Schema) LoadTagKeys(resp *Response) error {
+	return s.load(resp, func(m *MeasurementSchema, row map[string]interface{}) {
+		key, _ := row["tagKey"].(string)
+		m.Tags = append(m.Tags, key)
+	})
+}
+
+func (s *Schema) load(resp *Response, add func(*MeasurementSchema, map[string]interface{})) error {
+	if err := resp.Error(); err != nil {
+		return err
+	}
+
+	for _, r := range resp.Results {
+		if err := r.Error(); err != nil {
+			return err
+		}
+
+		for _, series := range r.Series {
+			m := s.measurement(series.Name)
+
+			for _, values := range series.Values {
+				row := map[string]interface{}{}
+				for i, c := range series.Columns {
+					if i < len(values) {
+						row[c] = values[i]
+					}
+				}
+
+				add(m, row)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) measurement(name string) *MeasurementSchema {
+	if s.Measurements == nil {
+		s.Measurements = map[string]*MeasurementSchema{}
+	}
+
+	m, ok := s.Measurements[name]
+	if !ok {
+		m = &MeasurementSchema{}
+		s.Measurements[name] = m
+	}
+
+	if m.Fields == nil {
+		m.Fields = map[string]KeyType{}
+	}
+
+	return m
+}
+
+func (m *MeasurementSchema) hasTag(key string) bool {
+	for _, t := range m.Tags {
+		if t == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ShowFieldKeys SHOW FIELD KEYS
+func ShowFieldKeys() Statement {
+	return &showStatement{what: "FIELD KEYS"}
+}
+
+// ShowTagKeys SHOW TAG KEYS
+func ShowTagKeys() Statement {
+	return &showStatement{what: "TAG KEYS"}
+}
+
+// numericFunctions functions that only accept numeric fields
+var numericFunctions = map[string]bool{
+	"MEAN": true, "SUM": true, "MEDIAN": true, "STDDEV": true, "SPREAD": true,
+	"INTEGRAL": true, "PERCENTILE": true, "MAX": true, "MIN": true,
+	"DERIVATIVE": true, "NON_NEGATIVE_DERIVATIVE": true, "DIFFERENCE": true,
+	"MOVING_AVERAGE": true, "CUMULATIVE_SUM": true,
+}
+
+var functionArgMatcher = regexp.MustCompile(`^(\w+)\(\s*"?([^",)]+)"?`)
+
+// ValidateAgainst Validate measurement, keys and types against a schema
+func (q *Query) ValidateAgainst(schema *Schema) error {
+	if schema == nil {
+		return ErrMissingSchema
+	}
+
+	m, ok := schema.Measurements[q.measurement]
+	if !ok {
+		return SchemaErrors{{Key: q.measurement, Message: "unknown measurement"}}
+	}
+
+	var errs SchemaErrors
+
+	for _, field := range q.fields {
+		selectField := strings.TrimSpace(strings.Split(field, "AS")[0])
+
+		if selectField == "*" {
+			continue
+		}
+
+		if match := functionArgMatcher.FindStringSubmatch(selectField); match != nil {
+			name, _ := splitCast(match[2])
+			if name == "*" {
+				continue
+			}
+
+			t, ok := m.Fields[name]
+			if !ok {
+				errs = append(errs, SchemaError{name, "unknown field"})
+			} else if numericFunctions[strings.ToUpper(match[1])] && !isNumeric(t) {
+				errs = append(errs, SchemaError{name, fmt.Sprintf("%s does not accept %s fields", match[1], t)})
+			}
+
+			continue
+		}
+
+		name, _ := splitCast(selectField)
+		if _, ok := m.Fields[name]; !ok && !m.hasTag(name) && name != "time" {
+			errs = append(errs, SchemaError{name, "unknown key"})
+		}
+	}
+
+	for _, tag := range q.groupByTags {
+		if tag != "*" && !m.hasTag(tag) {
+			errs = append(errs, SchemaError{tag, "unknown tag"})
+		}
+	}
+
+	errs = append(errs, q.validateCriteriaAgainst(m)...)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (q *Query) validateCriteriaAgainst(m *MeasurementSchema) SchemaErrors {
+	var errs SchemaErrors
+
+	tags := append([]Tag{}, q.and...)
+	tags = append(tags, q.or...)
+	if q.where != (Tag{}) {
+		tags = append([]Tag{q.where}, tags...)
+	}
+
+	for _, tag := range tags {
+		if err := validateTagAgainst(m, tag); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+
+	brackets := append([]QueryBuilder{}, q.andBrackets...)
+	brackets = append(brackets, q.orBrackets...)
+	if q.whereBrackets != nil {
+		brackets = append([]QueryBuilder{q.whereBrackets}, brackets...)
+	}
+
+	for _, b := range brackets {
+		if bq, ok := b.(*Query); ok {
+			errs = append(errs, bq.validateCriteriaAgainst(m)...)
+		}
+	}
+
+	return errs
+}
+
+func validateTagAgainst(m *MeasurementSchema, tag Tag) *SchemaError {
+	name, cast := splitCast(tag.key)
+	_, isVariable := tag.value.(Variable)
+
+	if name == "time" || (isVariable && name == "") {
+		return nil
+	}
+
+	t, isField := m.Fields[name]
+	isTag := m.hasTag(name)
+
+	switch {
+	case !isField && !isTag:
+		return &SchemaError{name, "unknown key"}
+	case cast == TagType && !isTag:
+		return &SchemaError{name, "not a tag"}
+	case cast == FieldType && !isField:
+		return &SchemaError{name, "not a field"}
+	}
+
+	// The value of a variable is only known to Grafana
+	if isVariable {
+		return nil
+	}
+
+	if _, ok := tag.value.(*regexp.Regexp); ok {
+		if !isTag && t != StringType {
+			return &SchemaError{name, fmt.Sprintf("regex on %s field", t)}
+		}
+
+		return nil
+	}
+
+	// Tags and casts to string compare as strings
+	if (isTag && cast != FieldType) || cast == TagType || cast == StringType {
+		return nil
+	}
+
+	if cast == IntegerType || cast == FloatType || cast == BooleanType {
+		t = cast
+	}
+
+	if !compatible(t, tag.value) {
+		return &SchemaError{name, fmt.Sprintf("%s field compared with %T", t, tag.value)}
+	}
+
+	return nil
+}
+
+func isNumeric(t KeyType) bool {
+	return t == FloatType || t == IntegerType
+}
+
+func compatible(t KeyType, value interface{}) bool {
+	switch value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return isNumeric(t)
+	case bool:
+		return t == BooleanType
+	default:
+		return t == StringType
+	}
+}
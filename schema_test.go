@@ -0,0 +1,157 @@
+package influxquerybuilder
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const schemaJSON = `{
+  "measurements": {
+    "cpu": {
+      "tags": ["host", "region"],
+      "fields": {"usage_idle": "float", "cores": "integer", "model": "string", "healthy": "boolean"}
+    }
+  }
+}`
+
+func loadTestSchema(t *testing.T) *Schema {
+	schema, err := LoadSchema(strings.NewReader(schemaJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return schema
+}
+
+func TestValidateAgainst(t *testing.T) {
+	schema := loadTestSchema(t)
+	err := New().
+		Select(`MEAN("usage_idle") AS idle`, "cores", "host").
+		From("cpu").
+		Where("time", ">", "2018-11-01T06:33:57.503Z").
+		And("host", "=~", regexp.MustCompile("^web")).
+		And("healthy", "=", true).
+		AndBrackets(New().Where("cores", ">", 2).Or("model", "=", "xeon")).
+		GroupByTag("region").
+		ValidateAgainst(schema)
+
+	assert(t, err, nil)
+}
+
+func TestValidateAgainstUnknownMeasurement(t *testing.T) {
+	err := New().Select("usage_idle").From("cpuu").ValidateAgainst(loadTestSchema(t))
+
+	assert(t, err.Error(), "influxquerybuilder: cpuu: unknown measurement")
+}
+
+func TestValidateAgainstNil(t *testing.T) {
+	assert(t, New().Select("usage_idle").From("cpu").ValidateAgainst(nil), ErrMissingSchema)
+}
+
+func TestValidateAgainstErrors(t *testing.T) {
+	err := New().
+		Select("usage_idel", `SUM("model")`).
+		From("cpu").
+		Where("cores", ">", "2").
+		Or("healthy", "=~", regexp.MustCompile("t")).
+		AndBrackets(New().Where("hots", "=", "a")).
+		GroupByTag("zone").
+		ValidateAgainst(loadTestSchema(t))
+
+	errs, ok := err.(SchemaErrors)
+	assert(t, ok, true)
+	assert(t, len(errs), 6)
+	assert(t, errs[0].Key, "usage_idel")
+	assert(t, errs[1].Message, "SUM does not accept string fields")
+	assert(t, errs[2].Key, "zone")
+	assert(t, errs[3].Message, "integer field compared with string")
+	assert(t, errs[4].Message, "regex on boolean field")
+	assert(t, errs[5].Key, "hots")
+}
+
+func TestValidateAgainstCast(t *testing.T) {
+	schema := loadTestSchema(t)
+
+	err := New().Select("cores").From("cpu").Where(TagKey("host"), "=", 1).ValidateAgainst(schema)
+	assert(t, err, nil)
+
+	err = New().Select("cores").From("cpu").Where(TagKey("cores"), "=", 1).ValidateAgainst(schema)
+	assert(t, err.(SchemaErrors)[0].Message, "not a tag")
+}
+
+func TestLoadFromShowKeys(t *testing.T) {
+	schema := NewSchema()
+	err := schema.LoadFieldKeys(&Response{Results: []Result{{Series: []Series{{
+		Name:    "mem",
+		Columns: []string{"fieldKey", "fieldType"},
+		Values:  [][]interface{}{{"used", "integer"}, {"used_percent", "float"}},
+	}}}}})
+	assert(t, err, nil)
+
+	err = schema.LoadTagKeys(&Response{Results: []Result{{Series: []Series{{
+		Name:    "mem",
+		Columns: []string{"tagKey"},
+		Values:  [][]interface{}{{"host"}},
+	}}}}})
+	assert(t, err, nil)
+
+	assert(t, schema.Measurements["mem"].Fields["used"], IntegerType)
+	assert(t, schema.Measurements["mem"].Tags[0], "host")
+
+	err = New().Select(`MAX("used")`).From("mem").Where("host", "=", "a").ValidateAgainst(schema)
+	assert(t, err, nil)
+
+	err = schema.LoadTagKeys(&Response{Results: []Result{{Err: "database not found"}}})
+	assert(t, err.Error(), "database not found")
+}
+
+const schemaYAML = `measurements:
+  cpu:
+    tags: [host, region]
+    fields:
+      usage_idle: float
+      cores: integer
+      model: string
+      healthy: boolean
+`
+
+func TestLoadSchemaYAML(t *testing.T) {
+	schema, err := LoadSchemaYAML(strings.NewReader(schemaYAML))
+	assert(t, err, nil)
+
+	if !reflect.DeepEqual(schema, loadTestSchema(t)) {
+		t.Errorf("Expected the schema of the JSON file but got %+v", schema.Measurements["cpu"])
+	}
+
+	_, err = LoadSchemaYAML(strings.NewReader("measurements:\n  cpu: [a"))
+	assert(t, errors.Is(err, ErrYAML), true)
+}
+
+func TestLoadSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{"schema.yml": schemaYAML, "schema.json": schemaJSON} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		schema, err := LoadSchemaFile(path)
+		assert(t, err, nil)
+		assert(t, schema.Measurements["cpu"].Fields["cores"], IntegerType)
+	}
+
+	_, err := LoadSchemaFile(filepath.Join(dir, "missing.json"))
+	assert(t, os.IsNotExist(err), true)
+}
+
+func TestShowKeys(t *testing.T) {
+	assert(t, ShowFieldKeys().Build(), "SHOW FIELD KEYS")
+	assert(t, ShowTagKeys().Build(), "SHOW TAG KEYS")
+}
@@ -0,0 +1,307 @@
+package influxquerybuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// QuerySpecVersion Current version of QuerySpec
+const QuerySpecVersion = 1
+
+// ErrSpecVersion QuerySpec of an unsupported version
+var ErrSpecVersion = errors.New("influxquerybuilder: unsupported query spec version")
+
+// QuerySpec Serialisable description of the whole builder state
+type QuerySpec struct {
+	Version             int           `json:"version" yaml:"version"`
+	Fields              []string      `json:"fields,omitempty" yaml:"fields,omitempty"`
+	Measurement         string        `json:"measurement,omitempty" yaml:"measurement,omitempty"`
+	RetentionPolicy     string        `json:"retentionPolicy,omitempty" yaml:"retentionPolicy,omitempty"`
+	Into                string        `json:"into,omitempty" yaml:"into,omitempty"`
+	IntoRetentionPolicy string        `json:"intoRetentionPolicy,omitempty" yaml:"intoRetentionPolicy,omitempty"`
+	Criteria            *CriteriaSpec `json:"criteria,omitempty" yaml:"criteria,omitempty"`
+	GroupByTime         string        `json:"groupByTime,omitempty" yaml:"groupByTime,omitempty"`
+	GroupByTags         []string      `json:"groupByTags,omitempty" yaml:"groupByTags,omitempty"`
+	Fill                interface{}   `json:"fill,omitempty" yaml:"fill,omitempty"`
+	Order               string        `json:"order,omitempty" yaml:"order,omitempty"`
+	Limit               *uint         `json:"limit,omitempty" yaml:"limit,omitempty"`
+	Offset              *uint         `json:"offset,omitempty" yaml:"offset,omitempty"`
+}
+
+// CriteriaSpec Criteria of a query or of brackets
+type CriteriaSpec struct {
+	Where         *ConditionSpec  `json:"where,omitempty" yaml:"where,omitempty"`
+	WhereBrackets *CriteriaSpec   `json:"whereBrackets,omitempty" yaml:"whereBrackets,omitempty"`
+	And           []ConditionSpec `json:"and,omitempty" yaml:"and,omitempty"`
+	Or            []ConditionSpec `json:"or,omitempty" yaml:"or,omitempty"`
+	AndBrackets   []CriteriaSpec  `json:"andBrackets,omitempty" yaml:"andBrackets,omitempty"`
+	OrBrackets    []CriteriaSpec  `json:"orBrackets,omitempty" yaml:"orBrackets,omitempty"`
+}
+
+// ConditionSpec A single comparison, Regex or Var is set instead of Value for
+// regex and template variable values. A Var without Key and Op is a whole
+// criteria such as $timeFilter
+type ConditionSpec struct {
+	Key   string      `json:"key" yaml:"key"`
+	Op    string      `json:"op" yaml:"op"`
+	Value interface{} `json:"value,omitempty" yaml:"value,omitempty"`
+	Regex string      `json:"regex,omitempty" yaml:"regex,omitempty"`
+	Var   string      `json:"var,omitempty" yaml:"var,omitempty"`
+}
+
+// NewFromSpec New QueryBuilder from a spec
+func NewFromSpec(spec QuerySpec) (QueryBuilder, error) {
+	return New().FromSpec(spec)
+}
+
+// ToSpec Get the serialisable spec of the builder
+func (q *Query) ToSpec() QuerySpec {
+	spec := QuerySpec{
+		Version:             QuerySpecVersion,
+		Fields:              append([]string(nil), q.fields...),
+		Measurement:         q.measurement,
+		RetentionPolicy:     q.retentionPolicy,
+		Into:                q.into,
+		IntoRetentionPolicy: q.intoRetentionPolicy,
+		Criteria:            q.criteriaSpec(),
+		GroupByTime:         strings.TrimSuffix(strings.TrimPrefix(q.groupByTime, "time("), ")"),
+		GroupByTags:         append([]string(nil), q.groupByTags...),
+		Fill:                q.fill,
+		Order:               q.order,
+	}
+
+	if q._limit {
+		limit := q.limit
+		spec.Limit = &limit
+	}
+
+	if q._offset {
+		offset := q.offset
+		spec.Offset = &offset
+	}
+
+	return spec
+}
+
+func (q *Query) criteriaSpec() *CriteriaSpec {
+	if q.where == (Tag{}) && q.whereBrackets == nil && q.and == nil && q.or == nil &&
+		q.andBrackets == nil && q.orBrackets == nil {
+		return nil
+	}
+
+	spec := &CriteriaSpec{}
+
+	if q.where != (Tag{}) {
+		c := conditionSpec(q.where)
+		spec.Where = &c
+	}
+
+	if q.whereBrackets != nil {
+		spec.WhereBrackets = q.whereBrackets.ToSpec().Criteria
+	}
+
+	for _, tag := range q.and {
+		spec.And = append(spec.And, conditionSpec(tag))
+	}
+
+	for _, tag := range q.or {
+		spec.Or = append(spec.Or, conditionSpec(tag))
+	}
+
+	for _, b := range q.andBrackets {
+		spec.AndBrackets = append(spec.AndBrackets, bracketSpec(b))
+	}
+
+	for _, b := range q.orBrackets {
+		spec.OrBrackets = append(spec.OrBrackets, bracketSpec(b))
+	}
+
+	return spec
+}
+
+func bracketSpec(b QueryBuilder) CriteriaSpec {
+	if c := b.ToSpec().Criteria; c != nil {
+		return *c
+	}
+
+	return CriteriaSpec{}
+}
+
+func conditionSpec(tag Tag) ConditionSpec {
+	if re, ok := tag.value.(*regexp.Regexp); ok {
+		return ConditionSpec{Key: tag.key, Op: tag.op, Regex: re.String()}
+	}
+
+	if v, ok := tag.value.(Variable); ok {
+		return ConditionSpec{Key: tag.key, Op: tag.op, Var: string(v)}
+	}
+
+	if t, ok := tag.value.(time.Time); ok {
+		return ConditionSpec{Key: tag.key, Op: tag.op, Value: t.UTC().Format(time.RFC3339Nano)}
+	}
+
+	return ConditionSpec{Key: tag.key, Op: tag.op, Value: tag.value}
+}
+
+// FromSpec Replace the builder state with a spec
+func (q *Query) FromSpec(spec QuerySpec) (QueryBuilder, error) {
+	if spec.Version != QuerySpecVersion {
+		return nil, fmt.Errorf("version %d: %w", spec.Version, ErrSpecVersion)
+	}
+
+	n := &Query{}
+	n.Select(spec.Fields...)
+	n.FromRP(spec.RetentionPolicy, spec.Measurement)
+	n.IntoRP(spec.IntoRetentionPolicy, spec.Into)
+
+	if spec.Criteria != nil {
+		if err := n.applyCriteriaSpec(*spec.Criteria); err != nil {
+			return nil, err
+		}
+	}
+
+	if isVariable(spec.GroupByTime) {
+		n.GroupByTime(&variableDuration{spec.GroupByTime})
+	} else if spec.GroupByTime != "" {
+		duration, err := ParseDuration(spec.GroupByTime)
+		if err != nil {
+			return nil, err
+		}
+
+		n.GroupByTime(duration)
+	}
+
+	n.GroupByTag(spec.GroupByTags...)
+
+	if spec.Fill != nil {
+		n.Fill(specValue(spec.Fill))
+	}
+
+	switch strings.ToUpper(spec.Order) {
+	case "":
+	case "ASC":
+		n.Asc()
+	case "DESC":
+		n.Desc()
+	default:
+		return nil, fmt.Errorf("influxquerybuilder: invalid order %q", spec.Order)
+	}
+
+	if spec.Limit != nil {
+		n.Limit(*spec.Limit)
+	}
+
+	if spec.Offset != nil {
+		n.Offset(*spec.Offset)
+	}
+
+	*q = *n
+
+	return q, nil
+}
+
+func (q *Query) applyCriteriaSpec(spec CriteriaSpec) error {
+	tag := func(c ConditionSpec) (Tag, error) {
+		if c.Regex != "" {
+			re, err := regexp.Compile(c.Regex)
+			if err != nil {
+				return Tag{}, err
+			}
+
+			return Tag{c.Key, c.Op, re}, nil
+		}
+
+		if c.Var != "" {
+			return Tag{c.Key, c.Op, Var(c.Var)}, nil
+		}
+
+		return Tag{c.Key, c.Op, specValue(c.Value)}, nil
+	}
+
+	brackets := func(c CriteriaSpec) (QueryBuilder, error) {
+		b := &Query{}
+		return b, b.applyCriteriaSpec(c)
+	}
+
+	if spec.Where != nil {
+		t, err := tag(*spec.Where)
+		if err != nil {
+			return err
+		}
+
+		q.where = t
+	}
+
+	if spec.WhereBrackets != nil {
+		b, err := brackets(*spec.WhereBrackets)
+		if err != nil {
+			return err
+		}
+
+		q.whereBrackets = b
+	}
+
+	for _, c := range spec.And {
+		t, err := tag(c)
+		if err != nil {
+			return err
+		}
+
+		q.and = append(q.and, t)
+	}
+
+	for _, c := range spec.Or {
+		t, err := tag(c)
+		if err != nil {
+			return err
+		}
+
+		q.or = append(q.or, t)
+	}
+
+	for _, c := range spec.AndBrackets {
+		b, err := brackets(c)
+		if err != nil {
+			return err
+		}
+
+		q.andBrackets = append(q.andBrackets, b)
+	}
+
+	for _, c := range spec.OrBrackets {
+		b, err := brackets(c)
+		if err != nil {
+			return err
+		}
+
+		q.orBrackets = append(q.orBrackets, b)
+	}
+
+	return nil
+}
+
+// specValue converts decoded JSON numbers back into integers where possible,
+// and times into RFC3339 strings
+func specValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+
+		f, _ := v.Float64()
+		return f
+	case float64:
+		if v == float64(int64(v)) && !strings.ContainsAny(fmt.Sprint(v), ".e") {
+			return int64(v)
+		}
+	}
+
+	return value
+}
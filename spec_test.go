@@ -0,0 +1,115 @@
+package influxquerybuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestSpecRoundTrip(t *testing.T) {
+	builder := New().
+		Select(`MEAN("temperature") AS temp`, "humidity").
+		IntoRP("rp_1y", "temperature_1h").
+		FromRP("rp_1h", "measurement").
+		Where("time", ">", "2018-11-01T06:33:57.503Z").
+		And("host", "=~", regexp.MustCompile(`^web/\d+`)).
+		Or("ratio", "<", 0.5).
+		AndBrackets(New().Where("cpu", "=", 1).Or("hot", "=", true)).
+		OrBrackets(New().WhereBrackets(New().Where("a", "=", "b"))).
+		GroupByTime(NewDuration().Minute(10)).
+		GroupByTag("host", "region").
+		Fill(0).
+		Desc().
+		Limit(10).
+		Offset(0)
+
+	data, err := json.Marshal(builder.ToSpec())
+	assert(t, err, nil)
+
+	var spec QuerySpec
+	assert(t, json.Unmarshal(data, &spec), nil)
+
+	q, err := NewFromSpec(spec)
+	assert(t, err, nil)
+	assert(t, q.Build(), builder.Build())
+	assert(t, q.GetQueryStruct().IsOffsetSet, true)
+}
+
+func TestSpecRoundTripTime(t *testing.T) {
+	start := time.Date(2018, 11, 1, 14, 33, 57, 503000000, time.FixedZone("UTC+8", 8*3600))
+	builder := New().
+		Select("temperature").
+		From("measurement").
+		Where("time", ">", start).
+		AndBrackets(New().Where("time", "<", start.Add(time.Hour)))
+
+	expected := `SELECT "temperature" FROM "measurement" WHERE "time" > '2018-11-01T06:33:57.503Z' AND ("time" < '2018-11-01T07:33:57.503Z')`
+	assert(t, builder.Build(), expected)
+
+	spec := builder.ToSpec()
+	assert(t, spec.Criteria.Where.Value, "2018-11-01T06:33:57.503Z")
+
+	q, err := NewFromSpec(spec)
+	assert(t, err, nil)
+	assert(t, q.Build(), expected)
+
+	data, err := json.Marshal(spec)
+	assert(t, err, nil)
+
+	var decoded QuerySpec
+	assert(t, json.Unmarshal(data, &decoded), nil)
+
+	q, err = NewFromSpec(decoded)
+	assert(t, err, nil)
+	assert(t, q.Build(), expected)
+
+	// Specs built in Go may hold times as well
+	spec.Criteria.Where.Value = start
+	q, err = NewFromSpec(spec)
+	assert(t, err, nil)
+	assert(t, q.Build(), expected)
+}
+
+func TestSpecJSON(t *testing.T) {
+	expected := `SELECT "temperature" FROM "measurement" WHERE "cpu" = 1 AND "ratio" > 0.5 AND "host" =~ /^web/ GROUP BY time(5m) FILL(previous)`
+	data := `{
+		"version": 1,
+		"fields": ["temperature"],
+		"measurement": "measurement",
+		"criteria": {
+			"where": {"key": "cpu", "op": "=", "value": 1},
+			"and": [
+				{"key": "ratio", "op": ">", "value": 0.5},
+				{"key": "host", "op": "=~", "regex": "^web"}
+			]
+		},
+		"groupByTime": "5m",
+		"fill": "previous"
+	}`
+
+	var spec QuerySpec
+	assert(t, json.Unmarshal([]byte(data), &spec), nil)
+
+	q, err := New().FromSpec(spec)
+	assert(t, err, nil)
+	assert(t, q.Build(), expected)
+}
+
+func TestFromSpecErrors(t *testing.T) {
+	_, err := NewFromSpec(QuerySpec{Version: 2})
+	assert(t, errors.Is(err, ErrSpecVersion), true)
+
+	_, err = NewFromSpec(QuerySpec{Version: QuerySpecVersion, GroupByTime: "5x"})
+	assert(t, err != nil, true)
+
+	_, err = NewFromSpec(QuerySpec{Version: QuerySpecVersion, Order: "sideways"})
+	assert(t, err != nil, true)
+
+	_, err = NewFromSpec(QuerySpec{
+		Version:  QuerySpecVersion,
+		Criteria: &CriteriaSpec{Where: &ConditionSpec{Key: "host", Op: "=~", Regex: "("}},
+	})
+	assert(t, err != nil, true)
+}
@@ -0,0 +1,228 @@
+package influxquerybuilder
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sqlAggregates InfluxQL functions with a SQL equivalent
+var sqlAggregates = map[string]string{
+	"MEAN": "avg", "SUM": "sum", "COUNT": "count", "MAX": "max", "MIN": "min",
+	"MEDIAN": "median", "STDDEV": "stddev",
+}
+
+var sqlCasts = map[KeyType]string{
+	IntegerType: "BIGINT",
+	FloatType:   "DOUBLE",
+	StringType:  "VARCHAR",
+	BooleanType: "BOOLEAN",
+}
+
+var sqlIntervalUnits = map[string]string{
+	"ns": "nanoseconds", "u": "microseconds", "ms": "milliseconds", "s": "seconds",
+	"m": "minutes", "h": "hours", "d": "days", "w": "weeks",
+}
+
+// BuildSQL Convert the query to the SQL dialect of InfluxDB 3. GROUP BY time
+// uses date_bin, or date_bin_gapfill unless FILL(none) is set.
+func (q *Query) BuildSQL() (string, error) {
+	if err := q.Validate(); err != nil {
+		return "", err
+	}
+
+	if q.into != "" {
+		return "", unsupported("INTO")
+	}
+
+	if q.retentionPolicy != "" {
+		return "", unsupported("retention policy %s", q.retentionPolicy)
+	}
+
+	items, err := q.selectItems()
+	if err != nil {
+		return "", err
+	}
+
+	fill := fmt.Sprint(q.fill)
+	if q.fill == nil {
+		fill = "null"
+	}
+
+	var columns []string
+	var groupBy []string
+
+	if q.groupByTime != "" {
+		interval, err := sqlInterval(q.groupByTime)
+		if err != nil {
+			return "", err
+		}
+
+		bin := "date_bin_gapfill"
+		if fill == "none" {
+			bin = "date_bin"
+		}
+
+		columns = append(columns, fmt.Sprintf("%s(INTERVAL '%s', time) AS time", bin, interval))
+		groupBy = append(groupBy, "1")
+	} else if q.fill != nil {
+		return "", unsupported("FILL without GROUP BY time")
+	}
+
+	for _, tag := range q.groupByTags {
+		if tag == "*" {
+			return "", unsupported("GROUP BY *")
+		}
+
+		columns = append(columns, quoteIdent(tag))
+		groupBy = append(groupBy, quoteIdent(tag))
+	}
+
+	for _, item := range items {
+		column, err := sqlColumn(item, q.groupByTime != "", fill)
+		if err != nil {
+			return "", err
+		}
+
+		columns = append(columns, column)
+	}
+
+	var buffer bytes.Buffer
+
+	buffer.WriteString(fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), quoteIdent(q.measurement)))
+
+	where, err := q.renderCriteria(sqlComparison, "AND", "OR")
+	if err != nil {
+		return "", err
+	}
+
+	if where != "" {
+		buffer.WriteString(" WHERE " + where)
+	}
+
+	if len(groupBy) > 0 {
+		buffer.WriteString(" GROUP BY " + strings.Join(groupBy, ", "))
+	}
+
+	if q.order != "" {
+		buffer.WriteString(" ORDER BY time " + q.order)
+	} else if q.groupByTime != "" {
+		buffer.WriteString(" ORDER BY time")
+	}
+
+	if q._limit {
+		buffer.WriteString(fmt.Sprintf(" LIMIT %d", q.limit))
+	}
+
+	if q._offset {
+		buffer.WriteString(fmt.Sprintf(" OFFSET %d", q.offset))
+	}
+
+	return buffer.String(), nil
+}
+
+func sqlColumn(item selectItem, grouped bool, fill string) (string, error) {
+	if item.key == "*" {
+		if item.function != "" || grouped {
+			return "", unsupported("* with aggregates")
+		}
+		return "*", nil
+	}
+
+	column := quoteIdent(item.key)
+	if t, ok := sqlCasts[item.cast]; ok {
+		column = fmt.Sprintf("CAST(%s AS %s)", column, t)
+	}
+
+	if item.function != "" {
+		fn, ok := sqlAggregates[item.function]
+		if !ok {
+			return "", unsupported("function %s", item.function)
+		}
+
+		column = fmt.Sprintf("%s(%s)", fn, column)
+
+		if grouped {
+			switch fill {
+			case "null", "none":
+			case "previous":
+				column = fmt.Sprintf("locf(%s)", column)
+			case "linear":
+				column = fmt.Sprintf("interpolate(%s)", column)
+			default:
+				if _, err := strconv.ParseFloat(fill, 64); err != nil {
+					return "", unsupported("FILL(%s)", fill)
+				}
+				column = fmt.Sprintf("coalesce(%s, %s)", column, fill)
+			}
+		}
+
+		if item.alias == "" {
+			item.alias = strings.ToLower(item.function)
+		}
+	} else if grouped {
+		return "", unsupported("field %s without aggregate", item.key)
+	}
+
+	if item.alias != "" {
+		column += " AS " + quoteIdent(item.alias)
+	}
+
+	return column, nil
+}
+
+// sqlInterval converts time(10m) into 10 minutes
+func sqlInterval(groupByTime string) (string, error) {
+	literal := strings.TrimSuffix(strings.TrimPrefix(groupByTime, "time("), ")")
+	i := strings.IndexFunc(literal, func(r rune) bool { return r < '0' || r > '9' })
+
+	if i <= 0 {
+		return "", unsupported("GROUP BY %s", groupByTime)
+	}
+
+	unit, ok := sqlIntervalUnits[literal[i:]]
+	if !ok {
+		return "", unsupported("GROUP BY %s", groupByTime)
+	}
+
+	return literal[:i] + " " + unit, nil
+}
+
+func sqlComparison(tag Tag) (string, error) {
+	name, cast := splitCast(tag.key)
+	column := quoteIdent(name)
+
+	if t, ok := sqlCasts[cast]; ok {
+		column = fmt.Sprintf("CAST(%s AS %s)", column, t)
+	}
+
+	if re, ok := tag.value.(*regexp.Regexp); ok {
+		op := "~"
+		if Operator(tag.op) == NotMatch {
+			op = "!~"
+		}
+		return fmt.Sprintf("%s %s %s", column, op, sqlString(re.String())), nil
+	}
+
+	if v, ok := tag.value.(Variable); ok {
+		return "", unsupported("template variable %s", v)
+	}
+
+	switch v := tag.value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%s %s %d", column, tag.op, v), nil
+	case float32, float64:
+		return fmt.Sprintf("%s %s %g", column, tag.op, v), nil
+	case bool:
+		return fmt.Sprintf("%s %s %t", column, tag.op, v), nil
+	default:
+		return fmt.Sprintf("%s %s %s", column, tag.op, sqlString(fmt.Sprintf("%s", v))), nil
+	}
+}
+
+// sqlString single quotes a SQL string, doubling embedded quotes
+func sqlString(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
@@ -0,0 +1,64 @@
+package influxquerybuilder
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestBuildSQL(t *testing.T) {
+	expected := `SELECT date_bin_gapfill(INTERVAL '10 minutes', time) AS time, "sensorId", locf(avg("temperature")) AS "temp" FROM "measurement" WHERE "time" > '2018-11-01T06:33:57.503Z' AND "time" < '2018-11-02T09:35:25Z' AND ("host" ~ '^web' OR "region" = 'it''s') GROUP BY 1, "sensorId" ORDER BY time DESC LIMIT 10`
+	q, err := New().
+		Select(`MEAN("temperature") AS temp`).
+		From("measurement").
+		Where("time", ">", "2018-11-01T06:33:57.503Z").
+		And("time", "<", "2018-11-02T09:35:25Z").
+		AndBrackets(New().Where("host", "=~", regexp.MustCompile("^web")).Or("region", "=", "it's")).
+		GroupByTime(NewDuration().Minute(10)).
+		GroupByTag("sensorId").
+		Fill("previous").
+		Desc().
+		Limit(10).
+		BuildSQL()
+
+	assert(t, err, nil)
+	assert(t, q, expected)
+}
+
+func TestBuildSQLRaw(t *testing.T) {
+	expected := `SELECT "temperature", CAST("humidity" AS DOUBLE) AS "hum" FROM "measurement" WHERE "cpu" != 0 LIMIT 5 OFFSET 10`
+	q, err := New().
+		Select("temperature", Cast("humidity", FloatType)+" AS hum").
+		From("measurement").
+		Where("cpu", "!=", 0).
+		Limit(5).
+		Offset(10).
+		BuildSQL()
+
+	assert(t, err, nil)
+	assert(t, q, expected)
+
+	expected = `SELECT date_bin(INTERVAL '1 hours', time) AS time, count("temperature") AS "count" FROM "measurement" GROUP BY 1 ORDER BY time`
+	q, err = New().
+		Select(`COUNT("temperature")`).
+		From("measurement").
+		GroupByTime(NewDuration().Hour(1)).
+		Fill("none").
+		BuildSQL()
+
+	assert(t, err, nil)
+	assert(t, q, expected)
+}
+
+func TestBuildSQLUnsupported(t *testing.T) {
+	for _, builder := range []QueryBuilder{
+		New().Select("a").FromRP("rp", "measurement"),
+		New().Select(`PERCENTILE("a", 95)`).From("measurement"),
+		New().Select("a").From("measurement").GroupByTime(NewDuration().Minute(1)),
+		New().Select(`MEAN("a")`).From("measurement").GroupByTime(NewDuration().Minute(1)).Fill("bogus"),
+		New().Select("a").From("measurement").Fill(0),
+	} {
+		_, err := builder.BuildSQL()
+		assert(t, errors.Is(err, ErrUnsupported), true)
+	}
+}
@@ -0,0 +1,89 @@
+package influxquerybuilder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Statement Statement interface, implemented by every non-SELECT builder
+type Statement interface {
+	Validate() error
+	Build() string
+}
+
+var (
+	// ErrEmptyName An identifier such as a database or user name is empty
+	ErrEmptyName = errors.New("influxquerybuilder: name must not be empty")
+	// ErrInvalidDuration A duration is unset or not allowed in its position
+	ErrInvalidDuration = errors.New("influxquerybuilder: invalid duration")
+	// ErrInvalidReplication Replication factor is lower than 1
+	ErrInvalidReplication = errors.New("influxquerybuilder: replication must be at least 1")
+)
+
+func validateName(kind string, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%s: %w", kind, ErrEmptyName)
+	}
+
+	return nil
+}
+
+func validateDuration(kind string, d Duration, allowInfinite bool) error {
+	if d == nil || !d.isSet() {
+		return fmt.Errorf("%s: %w", kind, ErrInvalidDuration)
+	}
+
+	if isVariable(d.getLiteral()) {
+		return fmt.Errorf("%s: template variables are not allowed: %w", kind, ErrInvalidDuration)
+	}
+
+	if d.isInfinite() && !allowInfinite {
+		return fmt.Errorf("%s: INF is not allowed: %w", kind, ErrInvalidDuration)
+	}
+
+	return nil
+}
+
+// showStatement SHOW <what> ["name"]
+type showStatement struct {
+	what  string
+	name  string
+	named bool
+}
+
+func (s *showStatement) Validate() error {
+	if s.named {
+		return validateName(s.what, s.name)
+	}
+
+	return nil
+}
+
+func (s *showStatement) Build() string {
+	if s.Validate() != nil {
+		return ""
+	}
+
+	if s.named {
+		return fmt.Sprintf("SHOW %s %s", s.what, quoteIdent(s.name))
+	}
+
+	return fmt.Sprintf("SHOW %s", s.what)
+}
+
+// quoteIdent double quotes an identifier, escaping backslashes and quotes
+func quoteIdent(name string) string {
+	name = strings.Replace(name, `\`, `\\`, -1)
+	name = strings.Replace(name, `"`, `\"`, -1)
+
+	return `"` + name + `"`
+}
+
+// quoteString single quotes a string literal, escaping backslashes and quotes
+func quoteString(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `'`, `\'`, -1)
+
+	return `'` + value + `'`
+}
@@ -0,0 +1,106 @@
+package influxquerybuilder
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"iter"
+)
+
+// Row A row of a series in a streamed response
+type Row struct {
+	StatementID int
+	Name        string
+	Tags        map[string]string
+	Columns     []string
+	Values      []interface{}
+	// Partial More rows of the series follow in a later chunk
+	Partial bool
+}
+
+// StreamDecoder Decoder of chunked /query?chunked=true responses, which reads
+// one chunk at a time so memory does not grow with the response
+type StreamDecoder struct {
+	decoder *json.Decoder
+	closer  io.Closer
+}
+
+// NewStreamDecoder New StreamDecoder reading chunks from r
+func NewStreamDecoder(r io.Reader) *StreamDecoder {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	closer, _ := r.(io.Closer)
+
+	return &StreamDecoder{decoder: decoder, closer: closer}
+}
+
+// Next Next chunk, io.EOF after the last one. Cancelling ctx stops between
+// chunks, a blocked read is only interrupted by the request context
+func (d *StreamDecoder) Next(ctx context.Context) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var resp Response
+	if err := d.decoder.Decode(&resp); err != nil {
+		return nil, err
+	}
+
+	if err := resp.Error(); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// Rows Iterate over the rows of all chunks. Iteration stops after the first
+// error, which is yielded with an empty row
+func (d *StreamDecoder) Rows(ctx context.Context) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		for {
+			resp, err := d.Next(ctx)
+			if err == io.EOF {
+				return
+			}
+
+			if err != nil {
+				yield(Row{}, err)
+				return
+			}
+
+			for _, r := range resp.Results {
+				if err := r.Error(); err != nil {
+					yield(Row{}, err)
+					return
+				}
+
+				for _, s := range r.Series {
+					for _, values := range s.Values {
+						row := Row{
+							StatementID: r.StatementID,
+							Name:        s.Name,
+							Tags:        s.Tags,
+							Columns:     s.Columns,
+							Values:      values,
+							Partial:     s.Partial,
+						}
+
+						if !yield(row, nil) {
+							return
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// Close Close the underlying reader if it is an io.Closer
+func (d *StreamDecoder) Close() error {
+	if d.closer == nil {
+		return nil
+	}
+
+	return d.closer.Close()
+}
@@ -0,0 +1,93 @@
+package influxquerybuilder
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+const chunkedResponse = `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"a"},"columns":["time","value"],"values":[["2020-01-01T00:00:00Z",1],["2020-01-01T00:00:01Z",2]],"partial":true}],"partial":true}]}
+{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"a"},"columns":["time","value"],"values":[["2020-01-01T00:00:02Z",3]]}],"partial":true}]}
+{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"b"},"columns":["time","value"],"values":[["2020-01-01T00:00:00Z",1.5]]}]}]}
+`
+
+func TestStreamDecoderRows(t *testing.T) {
+	decoder := NewStreamDecoder(strings.NewReader(chunkedResponse))
+
+	var rows []Row
+	for row, err := range decoder.Rows(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rows = append(rows, row)
+	}
+
+	assert(t, len(rows), 4)
+	assert(t, rows[0].Partial, true)
+	assert(t, rows[1].Values[1], json.Number("2"))
+	assert(t, rows[2].Partial, false)
+	assert(t, rows[2].Tags["host"], "a")
+	assert(t, rows[3].Tags["host"], "b")
+	assert(t, rows[3].Values[1], json.Number("1.5"))
+}
+
+func TestStreamDecoderNext(t *testing.T) {
+	decoder := NewStreamDecoder(strings.NewReader(chunkedResponse))
+	ctx := context.Background()
+
+	chunks := 0
+	for {
+		_, err := decoder.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		chunks++
+	}
+
+	assert(t, chunks, 3)
+}
+
+func TestStreamDecoderErrors(t *testing.T) {
+	decoder := NewStreamDecoder(strings.NewReader(`{"results":[{"statement_id":0,"error":"database not found: db"}]}`))
+	for _, err := range decoder.Rows(context.Background()) {
+		assert(t, err.Error(), "database not found: db")
+	}
+
+	decoder = NewStreamDecoder(strings.NewReader(`{"error":"timeout"}`))
+	for _, err := range decoder.Rows(context.Background()) {
+		assert(t, err.Error(), "timeout")
+	}
+
+	decoder = NewStreamDecoder(strings.NewReader(`{"results":[`))
+	for _, err := range decoder.Rows(context.Background()) {
+		assert(t, err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestStreamDecoderCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	decoder := NewStreamDecoder(strings.NewReader(chunkedResponse))
+
+	rows := 0
+	for _, err := range decoder.Rows(ctx) {
+		if err != nil {
+			assert(t, err, context.Canceled)
+			break
+		}
+
+		rows++
+		cancel()
+	}
+
+	// The rows of the chunk already read are still yielded
+	assert(t, rows, 2)
+}
@@ -0,0 +1,163 @@
+package influxquerybuilder
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidPrivilege Privilege is not READ, WRITE or ALL
+var ErrInvalidPrivilege = errors.New("influxquerybuilder: privilege must be READ, WRITE or ALL")
+
+// Privilege Privilege type
+type Privilege string
+
+// Privileges
+const (
+	ReadPrivilege  Privilege = "READ"
+	WritePrivilege Privilege = "WRITE"
+	AllPrivileges  Privilege = "ALL"
+)
+
+// UserBuilder UserBuilder interface
+type UserBuilder interface {
+	Admin() UserBuilder
+	Statement
+}
+
+// User User struct
+type User struct {
+	name     string
+	password string
+	admin    bool
+}
+
+// CreateUser CREATE USER "name" WITH PASSWORD 'password'
+func CreateUser(name, password string) UserBuilder {
+	return &User{name: name, password: password}
+}
+
+// Admin WITH ALL PRIVILEGES
+func (u *User) Admin() UserBuilder {
+	u.admin = true
+	return u
+}
+
+// Validate Validate the statement
+func (u *User) Validate() error {
+	if err := validateName("user", u.name); err != nil {
+		return err
+	}
+
+	return validateName("password", u.password)
+}
+
+// Build Build statement string, empty if the statement is invalid
+func (u *User) Build() string {
+	if u.Validate() != nil {
+		return ""
+	}
+
+	q := fmt.Sprintf("CREATE USER %s WITH PASSWORD %s", quoteIdent(u.name), quoteString(u.password))
+	if u.admin {
+		q += " WITH ALL PRIVILEGES"
+	}
+
+	return q
+}
+
+// setPassword SET PASSWORD FOR "name" = 'password'
+type setPassword struct {
+	name     string
+	password string
+}
+
+// SetPassword SET PASSWORD FOR "name" = 'password'
+func SetPassword(name, password string) Statement {
+	return &setPassword{name: name, password: password}
+}
+
+func (s *setPassword) Validate() error {
+	if err := validateName("user", s.name); err != nil {
+		return err
+	}
+
+	return validateName("password", s.password)
+}
+
+func (s *setPassword) Build() string {
+	if s.Validate() != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("SET PASSWORD FOR %s = %s", quoteIdent(s.name), quoteString(s.password))
+}
+
+// DropUser DROP USER "name"
+func DropUser(name string) Statement {
+	return &dropStatement{kind: "USER", name: name}
+}
+
+// ShowUsers SHOW USERS
+func ShowUsers() Statement {
+	return &showStatement{what: "USERS"}
+}
+
+// ShowGrants SHOW GRANTS FOR "name"
+func ShowGrants(name string) Statement {
+	return &showStatement{what: "GRANTS FOR", name: name, named: true}
+}
+
+// privilegeStatement GRANT|REVOKE <privilege> [ON "database"] TO|FROM "user"
+type privilegeStatement struct {
+	revoke    bool
+	privilege Privilege
+	database  string
+	user      string
+}
+
+// Grant GRANT READ|WRITE|ALL ON "database" TO "user", an empty database with
+// AllPrivileges grants admin privileges
+func Grant(privilege Privilege, database, user string) Statement {
+	return &privilegeStatement{privilege: privilege, database: database, user: user}
+}
+
+// Revoke REVOKE READ|WRITE|ALL ON "database" FROM "user", an empty database
+// with AllPrivileges revokes admin privileges
+func Revoke(privilege Privilege, database, user string) Statement {
+	return &privilegeStatement{revoke: true, privilege: privilege, database: database, user: user}
+}
+
+func (p *privilegeStatement) Validate() error {
+	switch p.privilege {
+	case ReadPrivilege, WritePrivilege, AllPrivileges:
+	default:
+		return ErrInvalidPrivilege
+	}
+
+	if err := validateName("user", p.user); err != nil {
+		return err
+	}
+
+	if p.privilege != AllPrivileges {
+		return validateName("database", p.database)
+	}
+
+	return nil
+}
+
+func (p *privilegeStatement) Build() string {
+	if p.Validate() != nil {
+		return ""
+	}
+
+	verb, to := "GRANT", "TO"
+	if p.revoke {
+		verb, to = "REVOKE", "FROM"
+	}
+
+	if p.database == "" {
+		return fmt.Sprintf("%s ALL PRIVILEGES %s %s", verb, to, quoteIdent(p.user))
+	}
+
+	return fmt.Sprintf("%s %s ON %s %s %s", verb, p.privilege, quoteIdent(p.database), to, quoteIdent(p.user))
+}
@@ -0,0 +1,73 @@
+package influxquerybuilder
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreateUser(t *testing.T) {
+	expected := `CREATE USER "tenant_a" WITH PASSWORD 'p@ss'`
+	q := CreateUser("tenant_a", "p@ss").Build()
+
+	assert(t, q, expected)
+
+	expected = `CREATE USER "root" WITH PASSWORD 'p@ss' WITH ALL PRIVILEGES`
+	q = CreateUser("root", "p@ss").Admin().Build()
+
+	assert(t, q, expected)
+}
+
+func TestCreateUserPasswordEscaping(t *testing.T) {
+	expected := `CREATE USER "tenant_a" WITH PASSWORD 'it\'s a \\ secret'`
+	q := CreateUser("tenant_a", `it's a \ secret`).Build()
+
+	assert(t, q, expected)
+}
+
+func TestCreateUserValidate(t *testing.T) {
+	assert(t, errors.Is(CreateUser("", "p@ss").Validate(), ErrEmptyName), true)
+	assert(t, errors.Is(CreateUser("tenant_a", "").Validate(), ErrEmptyName), true)
+}
+
+func TestSetPassword(t *testing.T) {
+	expected := `SET PASSWORD FOR "tenant_a" = 'n3w\'pass'`
+	q := SetPassword("tenant_a", "n3w'pass").Build()
+
+	assert(t, q, expected)
+}
+
+func TestDropUser(t *testing.T) {
+	expected := `DROP USER "tenant_a"`
+	q := DropUser("tenant_a").Build()
+
+	assert(t, q, expected)
+}
+
+func TestGrant(t *testing.T) {
+	expected := `GRANT READ ON "telemetry" TO "tenant_a"`
+	q := Grant(ReadPrivilege, "telemetry", "tenant_a").Build()
+	assert(t, q, expected)
+
+	expected = `GRANT ALL PRIVILEGES TO "root"`
+	q = Grant(AllPrivileges, "", "root").Build()
+	assert(t, q, expected)
+
+	assert(t, errors.Is(Grant(WritePrivilege, "", "tenant_a").Validate(), ErrEmptyName), true)
+	assert(t, Grant(Privilege("DELETE"), "telemetry", "tenant_a").Validate(), ErrInvalidPrivilege)
+}
+
+func TestRevoke(t *testing.T) {
+	expected := `REVOKE WRITE ON "telemetry" FROM "tenant_a"`
+	q := Revoke(WritePrivilege, "telemetry", "tenant_a").Build()
+	assert(t, q, expected)
+
+	expected = `REVOKE ALL PRIVILEGES FROM "root"`
+	q = Revoke(AllPrivileges, "", "root").Build()
+	assert(t, q, expected)
+}
+
+func TestShowUsersAndGrants(t *testing.T) {
+	assert(t, ShowUsers().Build(), `SHOW USERS`)
+	assert(t, ShowGrants("tenant_a").Build(), `SHOW GRANTS FOR "tenant_a"`)
+	assert(t, errors.Is(ShowGrants("").Validate(), ErrEmptyName), true)
+}
@@ -0,0 +1,216 @@
+package influxquerybuilder
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Variable Grafana template variable, rendered unquoted as $name
+type Variable string
+
+// Var Template variable value, e.g. Where("host", "=", Var("host")) for
+// "host" = $host, regex operators render /^$host$/
+func Var(name string) Variable {
+	return Variable(name)
+}
+
+// TimeFilter $timeFilter, a whole time criteria used with WhereVar/AndVar
+func TimeFilter() Variable {
+	return Var("timeFilter")
+}
+
+// String $name
+func (v Variable) String() string {
+	return "$" + string(v)
+}
+
+// WhereVar WHERE $name, the variable is the whole criteria
+func (q *Query) WhereVar(v Variable) QueryBuilder {
+	return q.Where("", "", v)
+}
+
+// AndVar AND $name, the variable is the whole criteria
+func (q *Query) AndVar(v Variable) QueryBuilder {
+	return q.And("", "", v)
+}
+
+// OrVar OR $name, the variable is the whole criteria
+func (q *Query) OrVar(v Variable) QueryBuilder {
+	return q.Or("", "", v)
+}
+
+// variableDuration GROUP BY time interval taken from a template variable
+type variableDuration struct {
+	literal string
+}
+
+// IntervalVar $__interval, e.g. GroupByTime(IntervalVar())
+func IntervalVar() Duration {
+	return &variableDuration{"$__interval"}
+}
+
+// IntervalMsVar ${__interval_ms}ms, the interval in milliseconds
+func IntervalMsVar() Duration {
+	return &variableDuration{"${__interval_ms}ms"}
+}
+
+// Nanoseconds Nanoseconds, replaces the variable by a fixed duration
+func (t *variableDuration) Nanoseconds(d uint) Duration {
+	return NewDuration().Nanoseconds(d)
+}
+
+// Microseconds Microseconds, replaces the variable by a fixed duration
+func (t *variableDuration) Microseconds(d uint) Duration {
+	return NewDuration().Microseconds(d)
+}
+
+// Milliseconds Milliseconds, replaces the variable by a fixed duration
+func (t *variableDuration) Milliseconds(d uint) Duration {
+	return NewDuration().Milliseconds(d)
+}
+
+// Second Second, replaces the variable by a fixed duration
+func (t *variableDuration) Second(d uint) Duration {
+	return NewDuration().Second(d)
+}
+
+// Minute Minute, replaces the variable by a fixed duration
+func (t *variableDuration) Minute(d uint) Duration {
+	return NewDuration().Minute(d)
+}
+
+// Hour Hour, replaces the variable by a fixed duration
+func (t *variableDuration) Hour(d uint) Duration {
+	return NewDuration().Hour(d)
+}
+
+// Day Day, replaces the variable by a fixed duration
+func (t *variableDuration) Day(d uint) Duration {
+	return NewDuration().Day(d)
+}
+
+// Week Week, replaces the variable by a fixed duration
+func (t *variableDuration) Week(d uint) Duration {
+	return NewDuration().Week(d)
+}
+
+// Infinite INF, replaces the variable by a fixed duration
+func (t *variableDuration) Infinite() Duration {
+	return NewDuration().Infinite()
+}
+
+func (t *variableDuration) getDuration() string {
+	return fmt.Sprintf("time(%s)", t.literal)
+}
+
+func (t *variableDuration) getLiteral() string {
+	return t.literal
+}
+
+func (t *variableDuration) isSet() bool {
+	return t.literal != ""
+}
+
+func (t *variableDuration) isInfinite() bool {
+	return false
+}
+
+// isVariable Whether a GROUP BY time interval or duration literal is a variable
+func isVariable(literal string) bool {
+	return strings.Contains(literal, "$")
+}
+
+// variableCriteria renders criteria holding a Variable value
+func variableCriteria(key string, tag Tag, v Variable) string {
+	switch {
+	case tag.key == "" && tag.op == "":
+		return v.String()
+	case Operator(tag.op).IsRegex():
+		return fmt.Sprintf(`%s %s /^%s$/`, key, tag.op, v)
+	default:
+		return fmt.Sprintf(`%s %s %s`, key, tag.op, v)
+	}
+}
+
+var variableMatcher = regexp.MustCompile(`\$(\w+)|\$\{(\w+)\}`)
+
+// Interpolate Substitute template variables of a built query with concrete
+// values, e.g. for local execution. Both $name and ${name} are replaced,
+// variables without a value are left as they are. Quoted strings,
+// identifiers and regex literals are left untouched, except for the
+// /^$name$/ regex of a Var
+func Interpolate(query string, values map[string]string) string {
+	replace := func(s string) string {
+		return variableMatcher.ReplaceAllStringFunc(s, func(match string) string {
+			name := strings.Trim(match, "${}")
+			if value, ok := values[name]; ok {
+				return value
+			}
+
+			return match
+		})
+	}
+
+	var buffer strings.Builder
+	start := 0
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		regex := c == '/' && strings.HasSuffix(strings.TrimRight(query[:i], " "), "~")
+		if c != '\'' && c != '"' && !regex {
+			continue
+		}
+
+		end := literalEnd(query, i)
+		literal := query[i:end]
+
+		if match := regexVariableMatcher.FindStringSubmatch(strings.Trim(literal, "/")); regex && match != nil {
+			if value, ok := values[match[1]]; ok {
+				literal = "/^" + value + "$/"
+			}
+		}
+
+		buffer.WriteString(replace(query[start:i]))
+		buffer.WriteString(literal)
+		start = end
+		i = end - 1
+	}
+
+	buffer.WriteString(replace(query[start:]))
+
+	return buffer.String()
+}
+
+// literalEnd End of the quoted string, identifier or regex starting at i
+func literalEnd(query string, i int) int {
+	for j := i + 1; j < len(query); j++ {
+		switch query[j] {
+		case '\\':
+			j++
+		case query[i]:
+			return j + 1
+		}
+	}
+
+	return len(query)
+}
+
+// TimeFilterValue Concrete value of $timeFilter for a time range
+func TimeFilterValue(start, end time.Time) string {
+	return fmt.Sprintf(
+		"time >= %s AND time <= %s",
+		quoteString(formatTime(start)),
+		quoteString(formatTime(end)),
+	)
+}
+
+// IntervalValues Concrete values of $__interval and $__interval_ms
+func IntervalValues(interval time.Duration) map[string]string {
+	ms := interval.Milliseconds()
+	return map[string]string{
+		"__interval":    fmt.Sprintf("%dms", ms),
+		"__interval_ms": fmt.Sprintf("%d", ms),
+	}
+}
@@ -0,0 +1,136 @@
+package influxquerybuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestVariables(t *testing.T) {
+	expected := `SELECT MEAN("value") FROM "cpu" WHERE $timeFilter AND "host" =~ /^$host$/ AND "dc" = $dc GROUP BY time($__interval),host FILL(null)`
+	q := New().
+		Select(`MEAN("value")`).
+		From("cpu").
+		WhereVar(TimeFilter()).
+		And("host", "=~", Var("host")).
+		And("dc", "=", Var("dc")).
+		GroupByTime(IntervalVar()).
+		GroupByTag("host").
+		Fill("null")
+
+	assert(t, q.Build(), expected)
+	assert(t, q.Validate(), nil)
+}
+
+func TestIntervalMsVar(t *testing.T) {
+	expected := `SELECT MAX("value") FROM "cpu" WHERE "host" = $host OR $extra GROUP BY time(${__interval_ms}ms)`
+	q := New().
+		Select(`MAX("value")`).
+		From("cpu").
+		Where("host", "=", Var("host")).
+		OrVar(Var("extra")).
+		GroupByTime(IntervalMsVar()).
+		Build()
+
+	assert(t, q, expected)
+}
+
+func TestVariableValidate(t *testing.T) {
+	err := New().Select("value").From("cpu").Where("", "==", TimeFilter()).Validate()
+	assert(t, errors.Is(err, ErrInvalidOperator), true)
+
+	err = New().Select("value").From("cpu").Where("host", "!~", Var("host")).Validate()
+	assert(t, err, nil)
+
+	err = CreateDatabase("db").Duration(IntervalVar()).Validate()
+	assert(t, errors.Is(err, ErrInvalidDuration), true)
+}
+
+func TestVariableConversion(t *testing.T) {
+	_, err := New().Select("value").From("cpu").WhereVar(TimeFilter()).BuildSQL()
+	assert(t, errors.Is(err, ErrUnsupported), true)
+
+	_, err = New().Select(`MEAN("value")`).From("cpu").GroupByTime(IntervalVar()).BuildFlux("telegraf")
+	assert(t, errors.Is(err, ErrUnsupported), true)
+}
+
+func TestVariableParse(t *testing.T) {
+	for _, query := range []string{
+		`SELECT MEAN("value") FROM "cpu" WHERE $timeFilter AND "host" =~ /^$host$/ GROUP BY time($__interval)`,
+		`SELECT MEAN("value") FROM "cpu" WHERE "host" = $host GROUP BY time(${__interval_ms}ms)`,
+	} {
+		assertParse(t, query, query)
+	}
+
+	assertParse(t, `SELECT "value" FROM "cpu" WHERE "host" =~ /^${host}$/`, `SELECT "value" FROM "cpu" WHERE "host" =~ /^$host$/`)
+}
+
+func TestVariableSpec(t *testing.T) {
+	q := New().
+		Select(`MEAN("value")`).
+		From("cpu").
+		WhereVar(TimeFilter()).
+		And("host", "=~", Var("host")).
+		GroupByTime(IntervalVar())
+
+	data, err := json.Marshal(q.ToSpec())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var spec QuerySpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewFromSpec(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, restored.Build(), q.Build())
+}
+
+func TestVariableValidateAgainst(t *testing.T) {
+	schema := NewSchema().AddMeasurement("cpu", []string{"host"}, map[string]KeyType{"value": FloatType})
+
+	err := New().Select("value").From("cpu").WhereVar(TimeFilter()).And("value", ">", Var("threshold")).ValidateAgainst(schema)
+	assert(t, err, nil)
+}
+
+func TestInterpolate(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := New().
+		Select(`MEAN("value")`).
+		From("cpu").
+		WhereVar(TimeFilter()).
+		And("host", "=~", Var("host")).
+		GroupByTime(IntervalVar()).
+		Build()
+
+	values := IntervalValues(10 * time.Second)
+	values["timeFilter"] = TimeFilterValue(start, start.Add(time.Hour))
+
+	expected := `SELECT MEAN("value") FROM "cpu" WHERE time >= '2020-01-01T00:00:00Z' AND time <= '2020-01-01T01:00:00Z' AND "host" =~ /^$host$/ GROUP BY time(10000ms)`
+	assert(t, Interpolate(query, values), expected)
+
+	assert(t, Interpolate(`time(${__interval_ms}ms)`, values), `time(10000ms)`)
+
+	// Quoted strings and regex literals are not substituted, but the regex
+	// of a Var is
+	values = map[string]string{"5": "five", "host": "web1", "dc": "'eu'"}
+	query = New().
+		Select("value").
+		From("cpu").
+		Where("note", "=", "cost $5 $host").
+		And("path", "=~", regexp.MustCompile(`^/\$host/`)).
+		And("host", "=~", Var("host")).
+		And("dc", "=", Var("dc")).
+		Build()
+
+	expected = `SELECT "value" FROM "cpu" WHERE "note" = 'cost $5 $host' AND "path" =~ /^\/\$host\// AND "host" =~ /^web1$/ AND "dc" = 'eu'`
+	assert(t, Interpolate(query, values), expected)
+	assert(t, Interpolate(`SELECT "$host" FROM "cpu" WHERE "a" = 'it\'s $host'`, values), `SELECT "$host" FROM "cpu" WHERE "a" = 'it\'s $host'`)
+}
@@ -0,0 +1,426 @@
+package influxquerybuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrYAML Malformed or unsupported YAML document
+var ErrYAML = errors.New("influxquerybuilder: malformed yaml")
+
+var yamlNumber = regexp.MustCompile(`^[-+]?(\d+(\.\d*)?|\.\d+)([eE][-+]?\d+)?$`)
+
+// yamlLine a line without its indentation and comment
+type yamlLine struct {
+	indent int
+	text   string
+	no     int
+}
+
+// yamlParser parser of the YAML subset of configuration files: block
+// mappings and sequences, single line flow collections and scalars. Anchors,
+// tags and multi-line strings are not supported
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// unmarshalYAML decodes a YAML document into v through its JSON tags
+func unmarshalYAML(data []byte, v interface{}) error {
+	doc, err := decodeYAML(data)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// decodeYAML decodes a YAML document into the model of encoding/json:
+// maps, slices, strings, bools, nil and json.Number
+func decodeYAML(data []byte) (interface{}, error) {
+	p := &yamlParser{}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(stripYAMLComment(line), " \t\r")
+		text := strings.TrimLeft(line, " ")
+
+		if text == "" || text == "---" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tab indentation: %w", i+1, ErrYAML)
+		}
+
+		p.lines = append(p.lines, yamlLine{indent: len(line) - len(text), text: text, no: i + 1})
+	}
+
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+
+	v, err := p.block(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.lines) {
+		return nil, p.errorf("unexpected indentation")
+	}
+
+	return v, nil
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	no := 0
+	if p.pos < len(p.lines) {
+		no = p.lines[p.pos].no
+	}
+
+	return fmt.Errorf("line %d: %s: %w", no, fmt.Sprintf(format, args...), ErrYAML)
+}
+
+// block a mapping or a sequence at the given indentation
+func (p *yamlParser) block(indent int) (interface{}, error) {
+	if isYAMLItem(p.lines[p.pos].text) {
+		return p.sequence(indent)
+	}
+
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) mapping(indent int) (interface{}, error) {
+	m := map[string]interface{}{}
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+
+		if line.indent > indent || isYAMLItem(line.text) {
+			return nil, p.errorf("unexpected indentation")
+		}
+
+		key, rest, ok := splitYAMLEntry(line.text)
+		if !ok {
+			return nil, p.errorf("expected key: value")
+		}
+		p.pos++
+
+		var v interface{}
+		var err error
+
+		if rest != "" {
+			v, err = p.value(rest)
+		} else if p.pos < len(p.lines) {
+			// A sequence may be indented like its key
+			next := p.lines[p.pos]
+			if next.indent > indent || next.indent == indent && isYAMLItem(next.text) {
+				v, err = p.block(next.indent)
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		m[key] = v
+	}
+
+	return m, nil
+}
+
+func (p *yamlParser) sequence(indent int) (interface{}, error) {
+	items := []interface{}{}
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent || line.indent == indent && !isYAMLItem(line.text) {
+			break
+		}
+
+		if line.indent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+
+		rest := strings.TrimLeft(line.text[1:], " ")
+
+		var v interface{}
+		var err error
+
+		switch {
+		case rest == "":
+			p.pos++
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				v, err = p.block(p.lines[p.pos].indent)
+			}
+		case rest[0] != '[' && rest[0] != '{' && isYAMLEntry(rest):
+			// "- key: value" starts a mapping indented like key
+			p.lines[p.pos] = yamlLine{indent: line.indent + len(line.text) - len(rest), text: rest, no: line.no}
+			v, err = p.mapping(p.lines[p.pos].indent)
+		default:
+			v, err = p.value(rest)
+			p.pos++
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, v)
+	}
+
+	return items, nil
+}
+
+// value a flow collection or a scalar
+func (p *yamlParser) value(text string) (interface{}, error) {
+	if strings.ContainsRune("|>&*!", rune(text[0])) {
+		return nil, p.errorf("unsupported %q", text)
+	}
+
+	if text[0] != '[' && text[0] != '{' {
+		return yamlScalar(text)
+	}
+
+	f := &yamlFlow{src: text}
+
+	v, err := f.value()
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+
+	if f.skipSpace(); f.pos < len(f.src) {
+		return nil, p.errorf("unexpected %q", f.src[f.pos:])
+	}
+
+	return v, nil
+}
+
+// yamlFlow parser of a [sequence] or {mapping} on a single line
+type yamlFlow struct {
+	src string
+	pos int
+}
+
+func (f *yamlFlow) skipSpace() {
+	for f.pos < len(f.src) && f.src[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+func (f *yamlFlow) value() (interface{}, error) {
+	f.skipSpace()
+
+	if f.pos >= len(f.src) {
+		return nil, errors.New("unterminated flow collection")
+	}
+
+	switch f.src[f.pos] {
+	case '[':
+		return f.collection(']', false)
+	case '{':
+		return f.collection('}', true)
+	}
+
+	return f.scalar(",]}")
+}
+
+func (f *yamlFlow) collection(end byte, mapping bool) (interface{}, error) {
+	f.pos++
+	items := []interface{}{}
+	m := map[string]interface{}{}
+
+	for {
+		f.skipSpace()
+		if f.pos >= len(f.src) {
+			return nil, errors.New("unterminated flow collection")
+		}
+
+		if f.src[f.pos] == end {
+			f.pos++
+			break
+		}
+
+		if mapping {
+			key, err := f.scalar(":,}")
+			if err != nil {
+				return nil, err
+			}
+
+			if f.pos >= len(f.src) || f.src[f.pos] != ':' {
+				return nil, fmt.Errorf("expected ':' after %v", key)
+			}
+			f.pos++
+
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+
+			m[fmt.Sprint(key)] = v
+		} else {
+			v, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, v)
+		}
+
+		f.skipSpace()
+		if f.pos < len(f.src) && f.src[f.pos] == ',' {
+			f.pos++
+		}
+	}
+
+	if mapping {
+		return m, nil
+	}
+
+	return items, nil
+}
+
+// scalar a quoted scalar, or a plain one ending before any of stop
+func (f *yamlFlow) scalar(stop string) (interface{}, error) {
+	f.skipSpace()
+	start := f.pos
+
+	if f.pos < len(f.src) && (f.src[f.pos] == '"' || f.src[f.pos] == '\'') {
+		end := quotedEnd(f.src, f.pos)
+		if end < 0 {
+			return nil, errors.New("unterminated quote")
+		}
+
+		f.pos = end + 1
+		f.skipSpace()
+
+		return yamlScalar(f.src[start : end+1])
+	}
+
+	for f.pos < len(f.src) && !strings.ContainsRune(stop, rune(f.src[f.pos])) {
+		f.pos++
+	}
+
+	return yamlScalar(strings.TrimSpace(f.src[start:f.pos]))
+}
+
+// yamlScalar a quoted string, or a plain null, bool, number or string
+func yamlScalar(text string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(text, `"`):
+		if quotedEnd(text, 0) != len(text)-1 {
+			return nil, fmt.Errorf("unexpected %q: %w", text, ErrYAML)
+		}
+
+		s, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v: %w", text, err, ErrYAML)
+		}
+
+		return s, nil
+	case strings.HasPrefix(text, "'"):
+		if quotedEnd(text, 0) != len(text)-1 {
+			return nil, fmt.Errorf("unexpected %q: %w", text, ErrYAML)
+		}
+
+		return strings.Replace(text[1:len(text)-1], "''", "'", -1), nil
+	}
+
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+
+	if yamlNumber.MatchString(text) {
+		return json.Number(strings.TrimPrefix(text, "+")), nil
+	}
+
+	return text, nil
+}
+
+// quotedEnd index of the quote closing the one at start, -1 if unterminated
+func quotedEnd(s string, start int) int {
+	quote := s[start]
+
+	for i := start + 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// splitYAMLEntry splits "key: value", the value is empty for "key:"
+func splitYAMLEntry(text string) (string, string, bool) {
+	keyEnd := 0
+
+	if text[0] == '"' || text[0] == '\'' {
+		end := quotedEnd(text, 0)
+		if end < 0 {
+			return "", "", false
+		}
+
+		keyEnd = end + 1
+	}
+
+	for i := keyEnd; i < len(text); i++ {
+		if text[i] != ':' || i+1 < len(text) && text[i+1] != ' ' {
+			continue
+		}
+
+		key, err := yamlScalar(strings.TrimSpace(text[:i]))
+		if err != nil || key == nil {
+			return "", "", false
+		}
+
+		return fmt.Sprint(key), strings.TrimSpace(text[i+1:]), true
+	}
+
+	return "", "", false
+}
+
+func isYAMLEntry(text string) bool {
+	_, _, ok := splitYAMLEntry(text)
+	return ok
+}
+
+func isYAMLItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// stripYAMLComment removes a # comment outside of quotes
+func stripYAMLComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case (c == '"' || c == '\'') && (i == 0 || strings.ContainsRune(" \t:-[{,", rune(line[i-1]))):
+			if end := quotedEnd(line, i); end > 0 {
+				i = end
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+
+	return line
+}
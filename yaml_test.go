@@ -0,0 +1,69 @@
+package influxquerybuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeYAML(t *testing.T) {
+	doc := `---
+# measurements of the telemetry database
+measurements:
+  cpu:
+    tags: [host, "region"]   # inline
+    fields:
+      usage_idle: float
+      "cores": integer
+  mem:
+    tags:
+    - host
+    - 'it''s'
+    fields: {used: integer, note: "a # b"}
+limits:
+  - name: points
+    value: 1.5e6
+  - enabled: true
+    none: ~
+`
+	v, err := decodeYAML([]byte(doc))
+	assert(t, err, nil)
+
+	expected := map[string]interface{}{
+		"measurements": map[string]interface{}{
+			"cpu": map[string]interface{}{
+				"tags":   []interface{}{"host", "region"},
+				"fields": map[string]interface{}{"usage_idle": "float", "cores": "integer"},
+			},
+			"mem": map[string]interface{}{
+				"tags":   []interface{}{"host", "it's"},
+				"fields": map[string]interface{}{"used": "integer", "note": "a # b"},
+			},
+		},
+		"limits": []interface{}{
+			map[string]interface{}{"name": "points", "value": json.Number("1.5e6")},
+			map[string]interface{}{"enabled": true, "none": nil},
+		},
+	}
+
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("Expected %v but got %v", expected, v)
+	}
+}
+
+func TestDecodeYAMLErrors(t *testing.T) {
+	for _, doc := range []string{
+		"a: 1\n  b: 2",
+		"a: [1, 2",
+		"a: |\n  text",
+		"a: &anchor 1",
+		"- a\nb: 1",
+		"a: \"open",
+		"just text",
+	} {
+		if _, err := decodeYAML([]byte(doc)); !errors.Is(err, ErrYAML) {
+			t.Errorf("Expected ErrYAML for %q but got %v", doc, err)
+		}
+	}
+}